@@ -2,53 +2,41 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"tg-timer/internal/bot"
-	"tg-timer/pkg/telegram"
+	"tg-timer/internal/app"
+	"tg-timer/internal/config"
 )
 
 func main() {
+	modeName := flag.String("mode", "polling", "how to receive updates: polling or webhook")
+	flag.Parse()
+
+	mode, err := app.ParseMode(*modeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Get bot token from environment
 	token := os.Getenv("BOT_TOKEN")
 	if token == "" {
 		log.Fatal("BOT_TOKEN environment variable is required")
 	}
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Setup signal handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Initialize components
-	telegramClient := telegram.NewClient(token)
-	timerManager := bot.NewTimerManager(telegramClient)
-	commandHandler := bot.NewCommandHandler(timerManager, telegramClient)
-
-	log.Println("Telegram timer bot started")
-
-	// Start bot in goroutine
-	go bot.Run(ctx, telegramClient, commandHandler)
-
-	// Wait for shutdown signal
-	<-sigChan
-	log.Println("Shutdown signal received")
-
-	// Cancel context to stop all operations
-	cancel()
-
-	// Stop all timers
-	timerManager.StopAll()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
-	// Give some time for cleanup
-	time.Sleep(2 * time.Second)
+	// Context is cancelled on shutdown signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("Bot stopped gracefully")
+	if err := app.New(token, cfg).Run(ctx, mode); err != nil {
+		log.Fatal(err)
+	}
 }
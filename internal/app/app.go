@@ -0,0 +1,247 @@
+// Package app wires bot components together and runs them in polling or
+// webhook mode with common startup and shutdown sequence
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/internal/config"
+	"tg-timer/internal/monitoring"
+	"tg-timer/internal/store"
+	"tg-timer/pkg/telegram"
+)
+
+// Mode is how bot receives updates
+type Mode string
+
+const (
+	ModePolling Mode = "polling" // bot requests updates with getUpdates
+	ModeWebhook Mode = "webhook" // Telegram sends updates to bot's HTTPS server
+)
+
+// ParseMode returns mode by name
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(name); mode {
+	case ModePolling, ModeWebhook:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown mode %q, expected polling or webhook", name)
+}
+
+// shutdownTimeout limits time spent on sending notifications in progress
+// and releasing resources after updates are drained
+const shutdownTimeout = 10 * time.Second
+
+// receiver passes updates from Telegram to dispatcher
+type receiver interface {
+	// start starts receiving updates without blocking
+	start(ctx context.Context) error
+	// stop stops receiving new updates, received ones are still dispatched
+	stop(ctx context.Context)
+	// close releases resources after received updates are handled
+	close(ctx context.Context)
+}
+
+// App is bot with all its components
+type App struct {
+	cfg          config.Config
+	mode         Mode
+	metrics      *monitoring.Metrics
+	telegram     *telegram.HTTPClient
+	timerManager *bot.TimerManager
+	commands     *bot.CommandHandler
+	dedup        *bot.Deduplicator // skips redelivered updates before commands
+	dispatcher   *bot.Dispatcher
+	store        *store.File // nil if timers are not saved
+	health       *health
+	admin        *http.Server // health and metrics endpoints on METRICS_ADDR
+
+	stopCheckpoints context.CancelFunc // stops periodic state saving
+	checkpointsDone chan struct{}      // closed when periodic saving is stopped
+}
+
+// New creates bot components
+func New(token string, cfg config.Config) *App {
+	return newWithOptions(token, cfg, telegram.Options{})
+}
+
+// newWithOptions creates bot components using Telegram client options
+func newWithOptions(token string, cfg config.Config, opts telegram.Options) *App {
+	botMetrics := monitoring.New()
+	opts.Observer = botMetrics
+	telegramClient := telegram.NewClientWithOptions(token, opts)
+	timerManager := bot.NewTimerManager(telegramClient, cfg.Limits)
+	telegramClient.OnChatMigrated(timerManager.MigrateChat)
+	commandHandler := bot.NewCommandHandler(timerManager, telegramClient, cfg.Limits)
+	botMetrics.Attach(timerManager, commandHandler)
+	botMetrics.AttachRateLimiter(telegramClient.Stats)
+
+	a := &App{
+		cfg:          cfg,
+		metrics:      botMetrics,
+		telegram:     telegramClient,
+		timerManager: timerManager,
+		commands:     commandHandler,
+		dedup:        bot.NewDeduplicator(commandHandler, cfg.Updates.DedupWindow),
+	}
+	a.dedup.SetObserver(botMetrics)
+	if cfg.Store.File != "" {
+		a.store = store.NewFile(cfg.Store.File)
+	}
+	return a
+}
+
+// Run runs bot in mode until ctx is cancelled, then shuts it down
+func (a *App) Run(ctx context.Context, mode Mode) error {
+	a.mode = mode
+	a.health = newHealth(mode, a.timerManager, a.telegram)
+
+	var r receiver
+	var err error
+	switch mode {
+	case ModePolling:
+		r, err = newPolling(a)
+	case ModeWebhook:
+		r, err = newWebhook(a)
+	default:
+		err = fmt.Errorf("unknown mode %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Received updates are handled during shutdown too, so handlers don't use ctx
+	a.dispatcher = bot.NewDispatcher(context.Background(), a.dedup, a.cfg.Updates)
+
+	if err := a.restore(); err != nil {
+		a.dispatcher.Stop()
+		return err
+	}
+
+	a.admin = newAdminServer(a.adminMux())
+	if err := startServer(a.admin, false); err != nil {
+		a.dispatcher.Stop()
+		return fmt.Errorf("failed to start metrics server: %w", err)
+	}
+
+	checkpointCtx, stopCheckpoints := context.WithCancel(ctx)
+	a.stopCheckpoints = stopCheckpoints
+	a.checkpointsDone = make(chan struct{})
+	go a.checkpoints(checkpointCtx, a.checkpointsDone)
+
+	if err := r.start(ctx); err != nil {
+		a.shutdown(r)
+		return err
+	}
+	log.Printf("Telegram timer bot started in %s mode", mode)
+
+	<-ctx.Done()
+	log.Println("Shutdown signal received")
+
+	a.shutdown(r)
+	log.Println("Bot stopped gracefully")
+	return nil
+}
+
+// shutdown stops receiving updates, waits for updates and notifications in
+// progress and saves timers that haven't fired
+func (a *App) shutdown(r receiver) {
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), a.cfg.Updates.DrainTimeout)
+	defer cancelDrain()
+
+	a.health.ready.Store(false)
+	r.stop(drainCtx)
+
+	// Wait for received updates, handlers still running at deadline are cancelled
+	if err := a.dispatcher.Shutdown(drainCtx); err != nil {
+		log.Printf("Updates were not drained in %s", a.cfg.Updates.DrainTimeout)
+	}
+
+	// Periodic save must not overwrite final one
+	a.stopCheckpoints()
+	<-a.checkpointsDone
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	timers, err := a.timerManager.Shutdown(ctx)
+	if err != nil {
+		log.Printf("Timer notifications interrupted: %v", err)
+	}
+	a.save(timers)
+
+	r.close(ctx)
+	if err := a.admin.Shutdown(ctx); err != nil {
+		log.Printf("Metrics server shutdown error: %v", err)
+	}
+}
+
+// newHTTPServer creates server with limits protecting it from slow or
+// misbehaving clients
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    16 << 10,
+	}
+}
+
+// startServer listens on server address and serves requests in background.
+// Listening happens synchronously, so busy port fails startup instead of
+// being only logged.
+func startServer(server *http.Server, useTLS bool) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var err error
+		if useTLS {
+			// Certificate is provided by server.TLSConfig
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("Server %s error: %v", server.Addr, err)
+		}
+	}()
+	return nil
+}
+
+// newAdminServer creates server for internal endpoints on METRICS_ADDR,
+// which is not exposed to Telegram in webhook mode
+func newAdminServer(handler http.Handler) *http.Server {
+	addr := os.Getenv("METRICS_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+	return newHTTPServer(addr, handler)
+}
+
+// serviceMux returns mux serving health endpoints
+func (a *App) serviceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", a.health.serveHealth)
+	mux.HandleFunc("/ready", a.health.serveReady)
+	return mux
+}
+
+// adminMux returns mux serving health and metrics endpoints
+func (a *App) adminMux() *http.ServeMux {
+	mux := a.serviceMux()
+	mux.Handle("/metrics", a.metrics.Registry.Handler())
+	return mux
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/internal/config"
+	"tg-timer/internal/store"
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+const testToken = "123:test"
+
+// startTestApp runs app in polling mode against fake Bot API server
+func startTestApp(t *testing.T, server *telegramtest.Server, cfg config.Config) (*App, context.CancelFunc, <-chan error) {
+	t.Helper()
+	t.Setenv("METRICS_ADDR", freeAddr(t))
+
+	a := newWithOptions(testToken, cfg, telegram.Options{
+		BaseURL:    server.URL,
+		RateLimits: &telegram.RateLimits{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx, ModePolling) }()
+	return a, cancel, done
+}
+
+// freeAddr returns local address with port nobody listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// waitSent waits until server got n messages
+func waitSent(t *testing.T, server *telegramtest.Server, n int) []telegram.SendMessageRequest {
+	t.Helper()
+
+	deadline := time.Now().Add(telegramtest.DefaultTimeout)
+	for {
+		sent := server.SentMessages()
+		if len(sent) >= n {
+			return sent
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d sent messages, got %d", n, len(sent))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAppSavesAndRestoresTimers(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Store.File = filepath.Join(t.TempDir(), "state.json")
+
+	_, cancel, done := startTestApp(t, server, cfg)
+	server.Inject(telegram.Update{Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/timer 1h",
+	}})
+	waitSent(t, server, 1)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var st state
+	if found, err := store.NewFile(cfg.Store.File).Load(&st); err != nil || !found {
+		t.Fatalf("expected saved state, got found %v, err %v", found, err)
+	}
+	if len(st.Timers) != 1 || st.Timers[0].ChatID != 1 || st.Timers[0].Duration != "1h0m0s" {
+		t.Fatalf("unexpected saved timers %+v", st.Timers)
+	}
+	if st.LastUpdateID != 1 {
+		t.Fatalf("expected high-water mark 1, got %d", st.LastUpdateID)
+	}
+
+	restarted, cancel, done := startTestApp(t, server, cfg)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(telegramtest.DefaultTimeout)
+	for restarted.timerManager.ActiveTimers() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected saved timer to be restored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Restored timers stay in store until next save in case bot crashes
+	if _, err := store.NewFile(cfg.Store.File).Load(&st); err != nil || len(st.Timers) != 1 {
+		t.Fatalf("expected saved timers to be kept after restore, got %+v, err %v", st.Timers, err)
+	}
+
+	// Update handled before restart is skipped when delivered again
+	server.Inject(telegram.Update{UpdateID: 1, Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/timer 1h",
+	}})
+	server.Inject(telegram.Update{UpdateID: 2, Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/status",
+	}})
+	if sent := waitSent(t, server, 2); len(sent) != 2 || !strings.Contains(sent[1].Text, "сработает") {
+		t.Fatalf("expected only status reply after restart, got %+v", sent)
+	}
+}
+
+func TestAppSavesStatePeriodically(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Store.File = filepath.Join(t.TempDir(), "state.json")
+	cfg.Store.SaveInterval = 10 * time.Millisecond
+
+	_, cancel, done := startTestApp(t, server, cfg)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	server.Inject(telegram.Update{UpdateID: 7, Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/timer 1h",
+	}})
+	waitSent(t, server, 1)
+
+	// State is saved without shutdown, so crash doesn't lose it
+	f := store.NewFile(cfg.Store.File)
+	deadline := time.Now().Add(telegramtest.DefaultTimeout)
+	for {
+		var st state
+		if found, _ := f.Load(&st); found && len(st.Timers) == 1 && st.LastUpdateID == 7 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected periodically saved timer and update 7, got %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAppNotReadyWhileStoreFails(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Store.File = filepath.Join(dir, "state.json")
+	cfg.Store.SaveInterval = 10 * time.Millisecond
+
+	_, cancel, done := startTestApp(t, server, cfg)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	readyURL := "http://" + os.Getenv("METRICS_ADDR") + "/ready"
+	waitReady := func(code int) {
+		t.Helper()
+
+		deadline := time.Now().Add(telegramtest.DefaultTimeout)
+		for {
+			got := 0
+			resp, err := http.Get(readyURL)
+			if err == nil {
+				resp.Body.Close()
+				if got = resp.StatusCode; got == code {
+					return
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected /ready to return %d, got %d, err %v", code, got, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitReady(http.StatusOK)
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	waitReady(http.StatusServiceUnavailable)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	waitReady(http.StatusOK)
+}
+
+func TestAppFailsOnBusyMetricsAddr(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer l.Close()
+	t.Setenv("METRICS_ADDR", l.Addr().String())
+
+	a := newWithOptions(testToken, config.Default(), telegram.Options{
+		BaseURL:    server.URL,
+		RateLimits: &telegram.RateLimits{},
+	})
+	if err := a.Run(context.Background(), ModePolling); err == nil {
+		t.Fatal("expected Run to fail when metrics address is busy")
+	}
+	if n := server.Requests("getUpdates"); n != 0 {
+		t.Fatalf("expected no updates to be requested, got %d requests", n)
+	}
+}
+
+func TestAppHandlesUpdatesBelowStaleWatermark(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.Store.File = filepath.Join(t.TempDir(), "state.json")
+	if err := store.NewFile(cfg.Store.File).Save(state{LastUpdateID: 100000}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	_, cancel, done := startTestApp(t, server, cfg)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Telegram starts update IDs anew after long inactivity
+	server.Inject(telegram.Update{UpdateID: 3, Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/timer 1h",
+	}})
+	waitSent(t, server, 1)
+}
+
+func TestAppIgnoresWatermarkInWebhookMode(t *testing.T) {
+	cfg := config.Default()
+	cfg.Store.File = filepath.Join(t.TempDir(), "state.json")
+	if err := store.NewFile(cfg.Store.File).Save(state{LastUpdateID: 7}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	a := New(testToken, cfg)
+	a.mode = ModeWebhook
+	a.dispatcher = bot.NewDispatcher(context.Background(), a.dedup, cfg.Updates)
+	defer a.dispatcher.Stop()
+	if err := a.restore(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if mark := a.dispatcher.Watermark(); mark != 0 {
+		t.Fatalf("expected watermark not to be restored in webhook mode, got %d", mark)
+	}
+
+	if err := a.dispatcher.Dispatch(telegram.Update{UpdateID: 5, Message: &telegram.Message{
+		Chat: telegram.Chat{ID: 1},
+		Text: "/help",
+	}}); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if err := a.writeState(nil); err != nil {
+		t.Fatalf("writeState failed: %v", err)
+	}
+	var st state
+	if _, err := store.NewFile(cfg.Store.File).Load(&st); err != nil || st.LastUpdateID != 0 {
+		t.Fatalf("expected watermark not to be saved in webhook mode, got %d, err %v", st.LastUpdateID, err)
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/pkg/telegram"
+)
+
+// health serves /health and /ready endpoints
+type health struct {
+	started      time.Time
+	mode         Mode
+	timerManager *bot.TimerManager
+	telegram     *telegram.HTTPClient
+	ready        atomic.Bool // bot receives updates
+
+	mu       sync.Mutex
+	storeErr error // error of last state save, nil if it succeeded
+}
+
+// healthStatus represents health endpoint response
+type healthStatus struct {
+	Status              string     `json:"status"`
+	Mode                Mode       `json:"mode"`
+	Uptime              string     `json:"uptime"`
+	UptimeSeconds       int64      `json:"uptime_seconds"`
+	ActiveTimers        int        `json:"active_timers"`
+	Ready               bool       `json:"ready"`
+	LastTelegramSuccess *time.Time `json:"last_telegram_success,omitempty"`
+	StoreError          string     `json:"store_error,omitempty"`
+}
+
+func newHealth(mode Mode, timerManager *bot.TimerManager, telegramClient *telegram.HTTPClient) *health {
+	return &health{
+		started:      time.Now(),
+		mode:         mode,
+		timerManager: timerManager,
+		telegram:     telegramClient,
+	}
+}
+
+// serveHealth reports that process is up
+func (h *health) serveHealth(w http.ResponseWriter, r *http.Request) {
+	h.write(w, http.StatusOK, "ok")
+}
+
+// setStoreError records result of periodic state save
+func (h *health) setStoreError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.storeErr = err
+}
+
+func (h *health) storeError() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.storeErr
+}
+
+// serveReady reports whether bot receives updates (polling loop is running
+// or webhook is registered) and state store is writable: state is saved
+// every STORE_SAVE_INTERVAL, so failing saves mean timers would be lost on
+// crash.
+func (h *health) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		h.write(w, http.StatusServiceUnavailable, "not ready")
+		return
+	}
+	if h.storeError() != nil {
+		h.write(w, http.StatusServiceUnavailable, "store unavailable")
+		return
+	}
+	h.write(w, http.StatusOK, "ready")
+}
+
+func (h *health) write(w http.ResponseWriter, code int, status string) {
+	uptime := time.Since(h.started)
+	response := healthStatus{
+		Status:        status,
+		Mode:          h.mode,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		ActiveTimers:  h.timerManager.ActiveTimers(),
+		Ready:         h.ready.Load(),
+	}
+	if last := h.telegram.LastSuccess(); !last.IsZero() {
+		response.LastTelegramSuccess = &last
+	}
+	if err := h.storeError(); err != nil {
+		response.StoreError = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
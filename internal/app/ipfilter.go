@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// telegramSubnets are networks Telegram sends webhook requests from
+var telegramSubnets = []netip.Prefix{
+	netip.MustParsePrefix("149.154.160.0/20"),
+	netip.MustParsePrefix("91.108.4.0/22"),
+}
+
+// ipFilter allows requests from listed networks; nil filter allows all
+type ipFilter []netip.Prefix
+
+// parseIPFilter parses comma-separated networks or IP addresses; "telegram"
+// stands for Telegram's subnets. Empty value disables filtering.
+func parseIPFilter(value string) (ipFilter, error) {
+	var filter ipFilter
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case item == "telegram":
+			filter = append(filter, telegramSubnets...)
+			continue
+		case !strings.Contains(item, "/"):
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", item, err)
+			}
+			filter = append(filter, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", item, err)
+		}
+		filter = append(filter, prefix.Masked())
+	}
+	return filter, nil
+}
+
+// allows reports whether request from remoteAddr ("host:port") is allowed
+func (f ipFilter) allows(remoteAddr string) bool {
+	if f == nil {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range f {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package app
+
+import "testing"
+
+func TestIPFilter(t *testing.T) {
+	filter, err := parseIPFilter("telegram, 10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("parseIPFilter failed: %v", err)
+	}
+
+	for addr, allowed := range map[string]bool{
+		"149.154.167.220:443":        true,
+		"91.108.6.1:1234":            true,
+		"[::ffff:149.154.161.1]:443": true,
+		"10.1.2.3:80":                true,
+		"192.0.2.1:5000":             true,
+		"192.0.2.2:5000":             false,
+		"8.8.8.8:53":                 false,
+		"garbage":                    false,
+	} {
+		if got := filter.allows(addr); got != allowed {
+			t.Errorf("allows(%q) = %t, expected %t", addr, got, allowed)
+		}
+	}
+
+	if filter, _ := parseIPFilter(""); !filter.allows("8.8.8.8:53") {
+		t.Error("expected empty filter to allow everything")
+	}
+	if _, err := parseIPFilter("not-a-network/8"); err == nil {
+		t.Error("expected invalid network to be rejected")
+	}
+}
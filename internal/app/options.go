@@ -0,0 +1,68 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"tg-timer/pkg/telegram"
+)
+
+// defaultAllowedUpdates are update types bot handles
+var defaultAllowedUpdates = []string{"message", "my_chat_member"}
+
+// webhookOptionsFromEnv reads setWebhook options from environment
+func webhookOptionsFromEnv(secretToken string) (telegram.WebhookOptions, error) {
+	opts := telegram.WebhookOptions{
+		SecretToken:    secretToken,
+		AllowedUpdates: defaultAllowedUpdates,
+		IPAddress:      os.Getenv("WEBHOOK_IP_ADDRESS"),
+	}
+
+	if value := os.Getenv("WEBHOOK_ALLOWED_UPDATES"); value != "" {
+		opts.AllowedUpdates = nil
+		for _, kind := range strings.Split(value, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" {
+				opts.AllowedUpdates = append(opts.AllowedUpdates, kind)
+			}
+		}
+	}
+
+	if value := os.Getenv("WEBHOOK_MAX_CONNECTIONS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 100 {
+			return opts, fmt.Errorf("invalid WEBHOOK_MAX_CONNECTIONS %q: must be 1-100", value)
+		}
+		opts.MaxConnections = n
+	}
+
+	if value := os.Getenv("WEBHOOK_DROP_PENDING_UPDATES"); value != "" {
+		drop, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid WEBHOOK_DROP_PENDING_UPDATES: %w", err)
+		}
+		opts.DropPendingUpdates = drop
+	}
+
+	if path := os.Getenv("WEBHOOK_CERTIFICATE"); path != "" {
+		cert, err := os.ReadFile(path)
+		if err != nil {
+			return opts, fmt.Errorf("failed to read WEBHOOK_CERTIFICATE: %w", err)
+		}
+		opts.Certificate = cert
+	} else if value := os.Getenv("TLS_SELF_SIGNED"); value != "" {
+		selfSigned, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid TLS_SELF_SIGNED: %w", err)
+		}
+		if selfSigned {
+			// Telegram trusts self-signed certificate only if it is uploaded
+			if opts.Certificate, err = os.ReadFile(os.Getenv("TLS_CERT_FILE")); err != nil {
+				return opts, fmt.Errorf("failed to read TLS_CERT_FILE: %w", err)
+			}
+		}
+	}
+
+	return opts, nil
+}
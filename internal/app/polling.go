@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+
+	"tg-timer/internal/bot"
+)
+
+// polling receives updates with getUpdates
+type polling struct {
+	app    *App
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newPolling(a *App) (*polling, error) {
+	return &polling{
+		app:  a,
+		done: make(chan struct{}),
+	}, nil
+}
+
+func (p *polling) start(ctx context.Context) error {
+	ctx, p.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(p.done)
+		bot.Run(ctx, p.app.telegram, p.app.dispatcher)
+	}()
+
+	p.app.health.ready.Store(true)
+	return nil
+}
+
+func (p *polling) stop(ctx context.Context) {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+	}
+}
+
+// close does nothing, polling holds no resources after stop
+func (p *polling) close(ctx context.Context) {}
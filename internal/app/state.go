@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tg-timer/internal/bot"
+)
+
+// state is snapshot of bot state kept in store
+type state struct {
+	Timers       []savedTimer `json:"timers"`
+	LastUpdateID int          `json:"last_update_id,omitempty"` // all updates up to it are handled, polling mode only
+}
+
+// savedTimer is timer that hasn't fired before shutdown
+type savedTimer struct {
+	ChatID       int64     `json:"chat_id"`
+	Duration     string    `json:"duration"`
+	StartTime    time.Time `json:"start_time"`
+	Notification string    `json:"notification"`
+}
+
+// restore schedules saved timers and restores watermark of handled updates
+func (a *App) restore() error {
+	if a.store == nil {
+		return nil
+	}
+
+	var st state
+	found, err := a.store.Load(&st)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	timers := make([]bot.Timer, 0, len(st.Timers))
+	for _, saved := range st.Timers {
+		duration, err := time.ParseDuration(saved.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration of saved timer for chat %d: %w", saved.ChatID, err)
+		}
+		timers = append(timers, bot.Timer{
+			ChatID:       saved.ChatID,
+			Duration:     duration,
+			StartTime:    saved.StartTime,
+			Notification: saved.Notification,
+		})
+	}
+	// Saved state is kept until it is overwritten by next successful save,
+	// so restored timers survive crash before that
+	a.timerManager.Restore(timers)
+	if a.keepsWatermark() {
+		a.dedup.Restore(st.LastUpdateID)
+		a.dispatcher.Restore(st.LastUpdateID)
+	}
+	return nil
+}
+
+// save writes timers and watermark of handled updates to store on shutdown
+func (a *App) save(timers []bot.Timer) {
+	if a.store == nil {
+		return
+	}
+
+	if err := a.writeState(timers); err != nil {
+		log.Printf("Failed to save timers: %v", err)
+		return
+	}
+	log.Printf("Saved %d timers to %s", len(timers), a.store.Path())
+}
+
+// checkpoints saves state every SaveInterval until ctx is cancelled, so crash
+// loses only recent timers and doesn't make handled updates be handled again
+func (a *App) checkpoints(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+	if a.store == nil {
+		return
+	}
+
+	ticker := time.NewTicker(a.cfg.Store.SaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := a.writeState(a.timerManager.Timers())
+			if err != nil {
+				log.Printf("Failed to save state: %v", err)
+			}
+			a.health.setStoreError(err)
+		}
+	}
+}
+
+// writeState writes timers and watermark of handled updates to store
+func (a *App) writeState(timers []bot.Timer) error {
+	st := state{Timers: make([]savedTimer, 0, len(timers))}
+	if a.keepsWatermark() {
+		st.LastUpdateID = a.dispatcher.Watermark()
+	}
+	for _, timer := range timers {
+		st.Timers = append(st.Timers, savedTimer{
+			ChatID:       timer.ChatID,
+			Duration:     timer.Duration.String(),
+			StartTime:    timer.StartTime,
+			Notification: timer.Notification,
+		})
+	}
+	return a.store.Save(st)
+}
+
+// keepsWatermark reports whether watermark of handled updates is saved and
+// restored. getUpdates returns updates in order, while webhook requests come
+// concurrently and failed ones are redelivered later, so greater ID may be
+// handled before lesser one arrives and watermark would skip it.
+func (a *App) keepsWatermark() bool {
+	return a.mode == ModePolling
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// allowedWebhookPorts are ports Telegram sends webhook requests to
+var allowedWebhookPorts = map[string]bool{"443": true, "80": true, "88": true, "8443": true}
+
+// certCheckInterval is how often certificate files are checked for changes
+const certCheckInterval = time.Minute
+
+// validateWebhookURL checks that Telegram accepts webhook URL: HTTPS on one
+// of allowed ports. Returns parsed URL.
+func validateWebhookURL(webhookURL string) (*url.URL, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("URL must use https, got %q", u.Scheme)
+	}
+	if port := u.Port(); port != "" && !allowedWebhookPorts[port] {
+		return nil, fmt.Errorf("port %s is not supported by Telegram, use 443, 80, 88 or 8443", port)
+	}
+	return u, nil
+}
+
+// certReloader serves TLS certificate loaded from files and reloads it when
+// files change, so renewed certificate is picked up without restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+	onReload func(certPEM []byte)
+}
+
+// newCertReloader loads certificate and key
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := cr.reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// OnReload registers fn to be called with certificate PEM after reload
+func (cr *certReloader) OnReload(fn func(certPEM []byte)) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.onReload = fn
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	return cr.cert, nil
+}
+
+// reload loads certificate from files, keeping previous one on error
+func (cr *certReloader) reload() error {
+	modTimes, err := cr.stat()
+	if err != nil {
+		return err
+	}
+	certPEM, err := os.ReadFile(cr.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	cr.mu.Lock()
+	cr.cert = &cert
+	cr.modTimes = modTimes
+	onReload := cr.onReload
+	cr.mu.Unlock()
+
+	if onReload != nil {
+		onReload(certPEM)
+	}
+	return nil
+}
+
+// changed reports whether certificate files were modified since last load
+func (cr *certReloader) changed() bool {
+	modTimes, err := cr.stat()
+	if err != nil {
+		return false
+	}
+
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	return modTimes != cr.modTimes
+}
+
+func (cr *certReloader) stat() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, name := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// watch reloads certificate on signal from reload channel or when files
+// change, until ctx is cancelled
+func (cr *certReloader) watch(ctx context.Context, reload <-chan os.Signal) {
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-ticker.C:
+			if !cr.changed() {
+				continue
+			}
+		}
+
+		if err := cr.reload(); err != nil {
+			log.Printf("Failed to reload TLS certificate: %v", err)
+			continue
+		}
+		log.Printf("TLS certificate reloaded from %s", cr.certFile)
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes new self-signed certificate and key for name
+func writeSelfSigned(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeSelfSigned(t, certFile, keyFile, "old.example.com")
+
+	certs, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader failed: %v", err)
+	}
+	var uploaded []byte
+	certs.OnReload(func(certPEM []byte) { uploaded = certPEM })
+
+	writeSelfSigned(t, certFile, keyFile, "new.example.com")
+	// Make sure modification time differs on filesystems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if !certs.changed() {
+		t.Fatal("expected certificate change to be detected")
+	}
+	if err := certs.reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+
+	cert, _ := certs.GetCertificate(nil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	if leaf.Subject.CommonName != "new.example.com" {
+		t.Fatalf("expected renewed certificate, got %q", leaf.Subject.CommonName)
+	}
+	if len(uploaded) == 0 {
+		t.Fatal("expected reload hook to get certificate")
+	}
+
+	// Broken files don't replace working certificate
+	os.WriteFile(keyFile, []byte("garbage"), 0o600)
+	if err := certs.reload(); err == nil {
+		t.Fatal("expected reload of broken key to fail")
+	}
+	if current, _ := certs.GetCertificate(nil); current != cert {
+		t.Fatal("expected previous certificate to be kept")
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for url, valid := range map[string]bool{
+		"https://example.com/webhook":      true,
+		"https://example.com:8443/webhook": true,
+		"https://example.com:88/webhook":   true,
+		"http://example.com/webhook":       false,
+		"https://example.com:9000/webhook": false,
+		"https://example.com:443/hook?x=1": true,
+	} {
+		if _, err := validateWebhookURL(url); (err == nil) != valid {
+			t.Errorf("validateWebhookURL(%q) = %v, expected valid %t", url, err, valid)
+		}
+	}
+}
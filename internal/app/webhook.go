@@ -0,0 +1,261 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/pkg/metrics"
+	"tg-timer/pkg/telegram"
+)
+
+// maxUpdateSize limits webhook request body, real updates are much smaller
+const maxUpdateSize = 1 << 20
+
+// webhook receives updates from Telegram on HTTP(S) server, which also
+// serves health endpoints; metrics are served on admin server only
+type webhook struct {
+	app           *App
+	port          string
+	path          string
+	url           string
+	opts          telegram.WebhookOptions
+	checkInterval time.Duration
+	certs         *certReloader // nil if TLS is terminated by proxy
+	secretToken   string
+	allowedIPs    ipFilter // nil if requests are accepted from any address
+	mismatches    *metrics.Counter
+	rejected      *metrics.CounterVec
+	server        *http.Server
+	registered    bool // webhook was set by start
+	reload        chan os.Signal
+	draining      atomic.Bool // new updates are rejected, Telegram redelivers them later
+}
+
+// newWebhook reads webhook configuration from environment
+func newWebhook(a *App) (*webhook, error) {
+	w := &webhook{
+		app:           a,
+		port:          os.Getenv("PORT"),
+		path:          os.Getenv("WEBHOOK_PATH"),
+		url:           os.Getenv("WEBHOOK_URL"),
+		checkInterval: 5 * time.Minute,
+	}
+	if w.port == "" {
+		w.port = "8443"
+	}
+
+	if w.url == "" {
+		return nil, fmt.Errorf("WEBHOOK_URL environment variable is required")
+	}
+	webhookURL, err := validateWebhookURL(w.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_URL: %w", err)
+	}
+
+	// Telegram posts to path of WEBHOOK_URL unless proxy rewrites it
+	if w.path == "" {
+		w.path = webhookURL.Path
+	}
+	if w.path == "" {
+		w.path = "/"
+	}
+
+	// Secret token proves that webhook request comes from Telegram
+	secretToken := os.Getenv("WEBHOOK_SECRET")
+	if secretToken == "" {
+		var err error
+		if secretToken, err = telegram.GenerateSecretToken(); err != nil {
+			return nil, err
+		}
+	} else if err := telegram.ValidateSecretToken(secretToken); err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_SECRET: %w", err)
+	}
+
+	w.secretToken = secretToken
+
+	if w.opts, err = webhookOptionsFromEnv(secretToken); err != nil {
+		return nil, fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
+	if value := os.Getenv("WEBHOOK_CHECK_INTERVAL"); value != "" {
+		if w.checkInterval, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOK_CHECK_INTERVAL: %w", err)
+		}
+	}
+
+	// Serve HTTPS directly if certificate is configured
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		if w.certs, err = newCertReloader(certFile, keyFile); err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		if !allowedWebhookPorts[w.port] {
+			log.Printf("Warning: port %s is not supported by Telegram, make sure it is mapped to 443, 80, 88 or 8443", w.port)
+		}
+	}
+
+	// Requests from other addresses are rejected, e.g. "telegram"
+	if w.allowedIPs, err = parseIPFilter(os.Getenv("WEBHOOK_ALLOWED_IPS")); err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_ALLOWED_IPS: %w", err)
+	}
+
+	w.mismatches = a.metrics.Registry.NewCounter("tg_timer_webhook_secret_mismatches_total",
+		"Webhook requests rejected because of wrong secret token.")
+	w.rejected = a.metrics.Registry.NewCounterVec("tg_timer_webhook_rejected_requests_total",
+		"Webhook requests rejected before processing by reason.", "reason")
+	mux := a.serviceMux()
+	mux.Handle(w.path, http.HandlerFunc(w.serveUpdate))
+
+	w.server = newHTTPServer(":"+w.port, mux)
+	if w.certs != nil {
+		w.server.TLSConfig = &tls.Config{
+			GetCertificate: w.certs.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+	}
+	return w, nil
+}
+
+func (w *webhook) start(ctx context.Context) error {
+	log.Printf("Starting webhook server on port %s (TLS: %t)", w.port, w.certs != nil)
+	if err := startServer(w.server, w.certs != nil); err != nil {
+		return fmt.Errorf("failed to start webhook server: %w", err)
+	}
+
+	if err := w.app.telegram.SetWebhook(ctx, w.url, w.opts); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	w.registered = true
+	w.app.health.ready.Store(true)
+	log.Printf("Webhook set to: %s", w.url)
+
+	// Check periodically that Telegram delivers updates to us
+	var checker *webhookChecker
+	if w.checkInterval > 0 {
+		checker = newWebhookChecker(w.app.telegram, w.url, w.opts, &w.app.health.ready)
+		w.app.metrics.Registry.NewGaugeFunc("tg_timer_webhook_pending_updates",
+			"Updates waiting for delivery according to last getWebhookInfo.", checker.pendingUpdates)
+		go checker.run(ctx, w.checkInterval)
+	}
+
+	// Reload certificate on SIGHUP or when files change
+	if w.certs != nil {
+		if w.opts.Certificate != nil && os.Getenv("WEBHOOK_CERTIFICATE") == "" {
+			// Telegram must get renewed self-signed certificate too
+			opts := w.opts
+			opts.DropPendingUpdates = false
+			w.certs.OnReload(func(certPEM []byte) {
+				opts.Certificate = certPEM
+				if checker != nil {
+					checker.setCertificate(certPEM)
+				}
+				if err := w.app.telegram.SetWebhook(ctx, w.url, opts); err != nil {
+					log.Printf("Failed to upload renewed certificate: %v", err)
+				}
+			})
+		}
+		w.reload = make(chan os.Signal, 1)
+		signal.Notify(w.reload, syscall.SIGHUP)
+		go w.certs.watch(ctx, w.reload)
+	}
+	return nil
+}
+
+// stop makes webhook reject new updates with 503. Server keeps running, so
+// health endpoints are available while received updates are drained.
+func (w *webhook) stop(ctx context.Context) {
+	w.draining.Store(true)
+	if w.reload != nil {
+		signal.Stop(w.reload)
+	}
+}
+
+func (w *webhook) close(ctx context.Context) {
+	// Webhook of another instance is left alone if start failed
+	if w.registered {
+		if err := w.app.telegram.DeleteWebhook(ctx); err != nil {
+			log.Printf("Failed to delete webhook: %v", err)
+		}
+	}
+	if err := w.server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+}
+
+// serveUpdate passes update from webhook request to dispatcher
+func (w *webhook) serveUpdate(rw http.ResponseWriter, r *http.Request) {
+	if !w.allowedIPs.allows(r.RemoteAddr) {
+		w.rejected.With("ip").Inc()
+		log.Printf("Rejected webhook request from %s: address is not allowed", r.RemoteAddr)
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.rejected.With("method").Inc()
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if w.draining.Load() {
+		// Telegram will redeliver update to next instance
+		http.Error(rw, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !telegram.CheckSecretToken(r, w.secretToken) {
+		w.mismatches.Inc()
+		log.Printf("Rejected webhook request from %s: wrong secret token", r.RemoteAddr)
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		w.rejected.With("content_type").Inc()
+		http.Error(rw, "Unsupported media type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var update telegram.Update
+	body := http.MaxBytesReader(rw, r.Body, maxUpdateSize)
+	if err := json.NewDecoder(body).Decode(&update); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.rejected.With("too_large").Inc()
+			log.Printf("Rejected webhook request from %s: body exceeds %d bytes", r.RemoteAddr, tooLarge.Limit)
+			http.Error(rw, "Request entity too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.rejected.With("bad_request").Inc()
+		log.Printf("Failed to decode update: %v", err)
+		http.Error(rw, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	switch err := w.app.dispatcher.Dispatch(update); err {
+	case nil:
+	case bot.ErrDispatcherStopped:
+		// Telegram will redeliver update later
+		http.Error(rw, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	default:
+		log.Printf("Dropping update %d: %v", update.UpdateID, err)
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
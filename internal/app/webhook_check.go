@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tg-timer/pkg/telegram"
+)
+
+// pendingUpdatesWarning is number of undelivered updates worth logging
+const pendingUpdatesWarning = 100
+
+// webhookChecker periodically verifies webhook with getWebhookInfo: logs
+// delivery errors and registers webhook again if its URL was changed, e.g.
+// by another process using same token
+type webhookChecker struct {
+	client        telegram.Client
+	url           string
+	registered    *atomic.Bool
+	pending       atomic.Int64
+	lastErrorDate int64
+
+	mu   sync.Mutex
+	opts telegram.WebhookOptions
+}
+
+func newWebhookChecker(client telegram.Client, url string, opts telegram.WebhookOptions, registered *atomic.Bool) *webhookChecker {
+	// Pending updates were already dealt with on registration
+	opts.DropPendingUpdates = false
+	return &webhookChecker{
+		client:     client,
+		url:        url,
+		opts:       opts,
+		registered: registered,
+	}
+}
+
+// run checks webhook every interval until ctx is cancelled
+func (wc *webhookChecker) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			wc.check(ctx)
+		}
+	}
+}
+
+// check verifies webhook once
+func (wc *webhookChecker) check(ctx context.Context) {
+	info, err := wc.client.GetWebhookInfo(ctx)
+	if err != nil {
+		log.Printf("Failed to get webhook info: %v", err)
+		return
+	}
+	wc.pending.Store(int64(info.PendingUpdateCount))
+
+	if info.LastErrorMessage != "" && info.LastErrorDate > wc.lastErrorDate {
+		wc.lastErrorDate = info.LastErrorDate
+		log.Printf("Telegram failed to deliver update at %s: %s (pending updates: %d)",
+			time.Unix(info.LastErrorDate, 0).UTC().Format(time.RFC3339), info.LastErrorMessage, info.PendingUpdateCount)
+	} else if info.PendingUpdateCount >= pendingUpdatesWarning {
+		log.Printf("Webhook has %d pending updates", info.PendingUpdateCount)
+	}
+
+	if info.URL == wc.url {
+		return
+	}
+
+	wc.mu.Lock()
+	opts := wc.opts
+	wc.mu.Unlock()
+
+	log.Printf("Webhook URL changed to %q, registering %s again", info.URL, wc.url)
+	wc.registered.Store(false)
+	if err := wc.client.SetWebhook(ctx, wc.url, opts); err != nil {
+		log.Printf("Failed to set webhook: %v", err)
+		return
+	}
+	wc.registered.Store(true)
+}
+
+// setCertificate replaces certificate uploaded on re-registration
+func (wc *webhookChecker) setCertificate(cert []byte) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	wc.opts.Certificate = cert
+}
+
+// pendingUpdates returns pending update count seen by last check
+func (wc *webhookChecker) pendingUpdates() float64 {
+	return float64(wc.pending.Load())
+}
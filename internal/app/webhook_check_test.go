@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+func TestWebhookCheckerReregistersDriftedURL(t *testing.T) {
+	client := telegramtest.NewClient()
+	ctx := context.Background()
+
+	opts := telegram.WebhookOptions{SecretToken: "secret", DropPendingUpdates: true}
+	var registered atomic.Bool
+	registered.Store(true)
+	checker := newWebhookChecker(client, "https://example.com/webhook", opts, &registered)
+
+	client.SetWebhook(ctx, "https://other.example.com/hook", telegram.WebhookOptions{})
+	checker.check(ctx)
+
+	if url := client.WebhookURL(); url != "https://example.com/webhook" {
+		t.Fatalf("expected webhook to be registered again, got %q", url)
+	}
+	got := client.WebhookOptions()
+	if got.SecretToken != "secret" {
+		t.Fatalf("expected secret token to be restored, got %q", got.SecretToken)
+	}
+	if got.DropPendingUpdates {
+		t.Fatal("expected pending updates to be kept on re-registration")
+	}
+	if !registered.Load() {
+		t.Fatal("expected webhook to be reported as registered")
+	}
+}
+
+func TestWebhookCheckerRecordsStatus(t *testing.T) {
+	client := telegramtest.NewClient()
+	ctx := context.Background()
+
+	var registered atomic.Bool
+	checker := newWebhookChecker(client, "https://example.com/webhook", telegram.WebhookOptions{}, &registered)
+	client.SetWebhook(ctx, "https://example.com/webhook", telegram.WebhookOptions{})
+	client.Inject(telegram.Update{Message: &telegram.Message{Text: "/help"}})
+	client.WebhookError("Connection refused")
+
+	checker.check(ctx)
+	if checker.lastErrorDate == 0 {
+		t.Fatal("expected delivery error to be noticed")
+	}
+	if pending := checker.pendingUpdates(); pending != 1 {
+		t.Fatalf("expected 1 pending update, got %v", pending)
+	}
+	if registered.Load() {
+		t.Fatal("expected unchanged webhook not to be registered again")
+	}
+}
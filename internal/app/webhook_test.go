@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tg-timer/internal/bot"
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+const testSecret = "test-secret"
+
+// newTestWebhook creates webhook receiver without starting server
+func newTestWebhook(t *testing.T) *webhook {
+	t.Helper()
+	return newTestWebhookURL(t, "https://example.com/webhook")
+}
+
+// newTestWebhookURL creates webhook receiver for webhookURL without starting server
+func newTestWebhookURL(t *testing.T, webhookURL string) *webhook {
+	t.Helper()
+	t.Setenv("WEBHOOK_URL", webhookURL)
+	t.Setenv("WEBHOOK_SECRET", testSecret)
+
+	server := telegramtest.NewServer(testToken)
+	t.Cleanup(server.Close)
+
+	cfg := config.Default()
+	a := newWithOptions(testToken, cfg, telegram.Options{BaseURL: server.URL, RateLimits: &telegram.RateLimits{}})
+	a.health = newHealth(ModeWebhook, a.timerManager, a.telegram)
+	a.dispatcher = bot.NewDispatcher(context.Background(), a.dedup, cfg.Updates)
+	t.Cleanup(func() {
+		a.dispatcher.Stop()
+		a.timerManager.StopAll()
+	})
+
+	w, err := newWebhook(a)
+	if err != nil {
+		t.Fatalf("newWebhook failed: %v", err)
+	}
+	return w
+}
+
+// postUpdate sends update to webhook and returns response status
+func postUpdate(w *webhook, body string) int {
+	return post(w, body, func(*http.Request) {})
+}
+
+// post sends webhook request, modified by prepare, and returns response status
+func post(w *webhook, body string, prepare func(r *http.Request)) int {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set(telegram.SecretTokenHeader, testSecret)
+	req.Header.Set("Content-Type", "application/json")
+	prepare(req)
+	rec := httptest.NewRecorder()
+	w.server.Handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookRejectsUpdatesWhileDraining(t *testing.T) {
+	w := newTestWebhook(t)
+	update := `{"update_id":1,"message":{"chat":{"id":1},"text":"/help"}}`
+
+	if code := postUpdate(w, update); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	w.stop(context.Background())
+	if code := postUpdate(w, update); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", code)
+	}
+
+	// Health endpoint is still served during drain
+	rec := httptest.NewRecorder()
+	w.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected health to be 200 while draining, got %d", rec.Code)
+	}
+}
+
+func TestWebhookDoesNotServeMetrics(t *testing.T) {
+	w := newTestWebhook(t)
+
+	rec := httptest.NewRecorder()
+	w.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected metrics to be unavailable on public listener, got %d", rec.Code)
+	}
+}
+
+func TestWebhookRejectsMalformedRequests(t *testing.T) {
+	w := newTestWebhook(t)
+	update := `{"update_id":1,"message":{"chat":{"id":1},"text":"/help"}}`
+
+	if code := post(w, update, func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") }); code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 for wrong content type, got %d", code)
+	}
+	if code := post(w, update, func(r *http.Request) { r.Header.Set("Content-Type", "application/json; charset=utf-8") }); code != http.StatusOK {
+		t.Fatalf("expected 200 for JSON with charset, got %d", code)
+	}
+
+	huge := `{"update_id":2,"message":{"chat":{"id":1},"text":"` + strings.Repeat("a", maxUpdateSize) + `"}}`
+	if code := postUpdate(w, huge); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for huge body, got %d", code)
+	}
+	if code := postUpdate(w, "{"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed JSON, got %d", code)
+	}
+
+	w.allowedIPs, _ = parseIPFilter("telegram")
+	if code := postUpdate(w, update); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for request from unknown address, got %d", code)
+	}
+	if code := post(w, update, func(r *http.Request) { r.RemoteAddr = "149.154.167.220:443" }); code != http.StatusOK {
+		t.Fatalf("expected 200 for request from Telegram, got %d", code)
+	}
+}
+
+func TestWebhookRejectsWrongSecret(t *testing.T) {
+	w := newTestWebhook(t)
+	update := `{"update_id":1,"message":{"chat":{"id":1},"text":"/help"}}`
+
+	if code := post(w, update, func(r *http.Request) { r.Header.Set(telegram.SecretTokenHeader, "wrong") }); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong secret, got %d", code)
+	}
+	if code := post(w, update, func(r *http.Request) { r.Header.Del(telegram.SecretTokenHeader) }); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for missing secret, got %d", code)
+	}
+	if code := postUpdate(w, update); code != http.StatusOK {
+		t.Fatalf("expected 200 for correct secret, got %d", code)
+	}
+
+	var b strings.Builder
+	if _, err := w.app.metrics.Registry.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "\ntg_timer_webhook_secret_mismatches_total 2\n") {
+		t.Fatalf("expected 2 secret mismatches in metrics:\n%s", b.String())
+	}
+}
+
+func TestWebhookPathFromURL(t *testing.T) {
+	update := `{"update_id":1,"message":{"chat":{"id":1},"text":"/help"}}`
+
+	for webhookURL, path := range map[string]string{
+		"https://example.com/tg/hook":  "/tg/hook",
+		"https://example.com":          "/",
+		"https://example.com:8443/bot": "/bot",
+	} {
+		w := newTestWebhookURL(t, webhookURL)
+		if w.path != path {
+			t.Errorf("expected path %q for %s, got %q", path, webhookURL, w.path)
+			continue
+		}
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(update))
+		req.Header.Set(telegram.SecretTokenHeader, testSecret)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		w.server.Handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200 for update posted to %s, got %d", path, rec.Code)
+		}
+	}
+
+	t.Setenv("WEBHOOK_PATH", "/internal")
+	if w := newTestWebhookURL(t, "https://example.com/public"); w.path != "/internal" {
+		t.Errorf("expected WEBHOOK_PATH to override URL path, got %q", w.path)
+	}
+}
+
+func TestWebhookStartFailsOnBusyPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer l.Close()
+
+	w := newTestWebhook(t)
+	w.server.Addr = l.Addr().String()
+	if err := w.start(context.Background()); err == nil {
+		t.Fatal("expected start to fail when port is busy")
+	}
+	if w.registered || w.app.health.ready.Load() {
+		t.Fatal("expected webhook not to be registered after failed start")
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"tg-timer/pkg/telegram"
 )
 
-// Run runs the main bot loop
-func Run(ctx context.Context, telegramClient telegram.Client, commandHandler *CommandHandler) {
+// Run runs the main bot loop passing updates to dispatcher
+func Run(ctx context.Context, telegramClient telegram.Client, dispatcher *Dispatcher) {
 	var lastUpdateID int
 
 	for {
@@ -41,15 +41,9 @@ func Run(ctx context.Context, telegramClient telegram.Client, commandHandler *Co
 					lastUpdateID = update.UpdateID
 				}
 
-				// Handle update in goroutine to avoid blocking
-				go func(u telegram.Update) {
-					defer func() {
-						if r := recover(); r != nil {
-							log.Printf("Panic in update handler: %v", r)
-						}
-					}()
-					commandHandler.HandleUpdate(ctx, u)
-				}(update)
+				if err := dispatcher.Dispatch(update); err != nil {
+					log.Printf("Dropping update %d: %v", update.UpdateID, err)
+				}
 			}
 		}
 	}
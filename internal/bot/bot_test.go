@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tg-timer/internal/clock"
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+// startTestBot runs bot loop on fake client and clock until test ends
+func startTestBot(t *testing.T) (*telegramtest.Client, *clock.Fake) {
+	t.Helper()
+
+	limits := config.Default().Limits
+	client := telegramtest.NewClient()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	timerManager := NewTimerManagerWithClock(client, limits, clk)
+	commandHandler := NewCommandHandler(timerManager, client, limits)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dispatcher := NewDispatcher(ctx, commandHandler, config.Default().Updates)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(ctx, client, dispatcher)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		dispatcher.Stop()
+		timerManager.StopAll()
+	})
+
+	return client, clk
+}
+
+func TestRunTimerExpires(t *testing.T) {
+	client, clk := startTestBot(t)
+
+	telegramtest.NewConversation(t, client, 1, clk.Advance).
+		Send("/timer 5s").
+		Expect("Таймер на 5 секунд установлен.").
+		Advance(4 * time.Second).
+		ExpectNothing().
+		Advance(time.Second).
+		Expect("Время вышло!")
+}
+
+func TestRunStatusAndCancel(t *testing.T) {
+	client, clk := startTestBot(t)
+
+	telegramtest.NewConversation(t, client, 1, clk.Advance).
+		Send("/timer 1h30m").
+		Expect("Таймер на 1 час 30 минут установлен.").
+		Advance(9 * time.Minute).
+		Send("/status").
+		Expect("Таймер сработает через 1 час 21 минуту.").
+		Send("/cancel").
+		Expect("Таймер отменён.").
+		Advance(2 * time.Hour).
+		ExpectNothing().
+		Send("/cancel").
+		Expect("Активный таймер не найден.")
+}
+
+func TestRunCancelRightAfterTimer(t *testing.T) {
+	client, clk := startTestBot(t)
+
+	telegramtest.NewConversation(t, client, 1, clk.Advance).
+		Send("/timer 5m").
+		Send("/cancel").
+		Expect("Таймер на 5 минут установлен.").
+		Expect("Таймер отменён.").
+		Advance(5 * time.Minute).
+		ExpectNothing()
+}
+
+func TestRunLocale(t *testing.T) {
+	client, clk := startTestBot(t)
+
+	conv := telegramtest.NewConversation(t, client, 1, clk.Advance).WithLanguage("en-US")
+	conv.Send("/timer 1m").
+		Expect("Timer set for 1 minute.").
+		Send("/lang ru").
+		Expect("Язык переключён на русский.").
+		Advance(time.Minute).
+		Expect("Time's up!").
+		Send("/timer 22m").
+		Expect("Таймер на 22 минуты установлен.")
+}
+
+func TestRunReminderPhrase(t *testing.T) {
+	client, clk := startTestBot(t)
+
+	telegramtest.NewConversation(t, client, 1, clk.Advance).
+		Send("напомни через 10 минут позвонить маме").
+		Expect("Напомню через 10 минут: позвонить маме").
+		Advance(10 * time.Minute).
+		Expect("Время вышло! Напоминание: позвонить маме")
+
+	telegramtest.NewConversation(t, client, 2, clk.Advance).
+		InGroup().
+		Send("через 5 минут").
+		ExpectNothing()
+}
+
+func TestRunInvalidDuration(t *testing.T) {
+	client, _ := startTestBot(t)
+
+	telegramtest.NewConversation(t, client, 1, nil).
+		Send("/timer 1h30x").
+		Expect("Неверный формат времени: неизвестная единица в «x» (ввод: «1h30x»).\nПримеры: /timer 30s, /timer 1h30m, /timer PT1H30M").
+		Send("/timer 25h").
+		Expect("Максимальное время таймера - 1 день")
+}
+
+func TestHandleBotRemovedFromGroup(t *testing.T) {
+	limits := config.Default().Limits
+	tm, client, _ := newTestTimerManager(t, limits)
+	commandHandler := NewCommandHandler(tm, client, limits)
+	ctx := context.Background()
+
+	group := telegram.Chat{ID: -100, Type: "group"}
+	commandHandler.HandleUpdate(ctx, telegram.Update{Message: &telegram.Message{Text: "/lang en", Chat: group}})
+	commandHandler.HandleUpdate(ctx, telegram.Update{Message: &telegram.Message{Text: "/timer 5m", Chat: group}})
+	if !tm.HasActiveTimer(group.ID) {
+		t.Fatal("expected active timer in group")
+	}
+
+	commandHandler.HandleUpdate(ctx, telegram.Update{MyChatMember: &telegram.ChatMemberUpdated{
+		Chat:          group,
+		OldChatMember: telegram.ChatMember{Status: "member"},
+		NewChatMember: telegram.ChatMember{Status: "kicked"},
+	}})
+
+	if tm.HasActiveTimer(group.ID) {
+		t.Fatal("expected timers to be purged after bot was kicked")
+	}
+	if _, ok := commandHandler.settings.Locale(group.ID); ok {
+		t.Fatal("expected settings to be purged after bot was kicked")
+	}
+}
@@ -2,13 +2,13 @@ package bot
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
+	"tg-timer/internal/config"
+	"tg-timer/internal/i18n"
 	"tg-timer/pkg/telegram"
 )
 
@@ -16,38 +16,117 @@ import (
 type CommandHandler struct {
 	timerManager *TimerManager
 	telegram     telegram.Client
+	settings     *ChatSettings
+	limits       config.Limits
+	observer     Observer
 }
 
 // NewCommandHandler creates new command handler
-func NewCommandHandler(timerManager *TimerManager, telegram telegram.Client) *CommandHandler {
-	return &CommandHandler{
+func NewCommandHandler(timerManager *TimerManager, telegram telegram.Client, limits config.Limits) *CommandHandler {
+	ch := &CommandHandler{
 		timerManager: timerManager,
 		telegram:     telegram,
+		settings:     NewChatSettings(),
+		limits:       limits,
+		observer:     nopObserver{},
 	}
+	timerManager.OnChatGone(ch.settings.Delete)
+	timerManager.OnChatMigrated(ch.settings.Migrate)
+	return ch
+}
+
+// SetObserver sets receiver of update and command events, must be called
+// before updates are handled
+func (ch *CommandHandler) SetObserver(observer Observer) {
+	ch.observer = observer
 }
 
 // HandleUpdate processes incoming update
 func (ch *CommandHandler) HandleUpdate(ctx context.Context, update telegram.Update) {
-	if update.Message == nil || update.Message.Text == "" {
+	switch {
+	case update.Message != nil:
+		ch.observer.UpdateReceived("message")
+	case update.MyChatMember != nil:
+		ch.observer.UpdateReceived("my_chat_member")
+	default:
+		ch.observer.UpdateReceived("other")
+	}
+
+	if update.MyChatMember != nil {
+		ch.handleMyChatMember(update.MyChatMember)
+		return
+	}
+
+	if update.Message == nil {
+		return
+	}
+
+	// Service message in old group after its migration to supergroup
+	if update.Message.MigrateToChatID != 0 {
+		ch.timerManager.MigrateChat(update.Message.Chat.ID, update.Message.MigrateToChatID)
 		return
 	}
 
+	if update.Message.Text == "" {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	locale := ch.resolveLocale(update.Message)
+
 	command := parseCommand(update.Message.Text)
 	if command == nil {
+		// Plain messages are timer phrases in private chats ("через 5 минут")
+		if update.Message.Chat.Type == "private" {
+			ch.handleReminderPhrase(ctx, chatID, locale, update.Message.Text)
+		}
 		return
 	}
 
-	chatID := update.Message.Chat.ID
 	log.Printf("Received command '%s' from chat %d", command.Name, chatID)
 
+	switch command.Name {
+	case "timer", "cancel", "status", "lang":
+		ch.observer.CommandReceived(command.Name)
+	default:
+		ch.observer.CommandReceived("unknown")
+	}
+
 	switch command.Name {
 	case "timer":
-		ch.handleTimerCommand(ctx, chatID, command.Args)
+		ch.handleTimerCommand(ctx, chatID, locale, command.Args)
 	case "cancel":
-		ch.handleCancelCommand(ctx, chatID)
+		ch.handleCancelCommand(ctx, chatID, locale)
+	case "status":
+		ch.handleStatusCommand(ctx, chatID, locale)
+	case "lang":
+		ch.handleLangCommand(ctx, chatID, locale, command.Args)
 	default:
-		ch.sendUnknownCommandMessage(ctx, chatID)
+		ch.sendUnknownCommandMessage(ctx, chatID, locale)
+	}
+}
+
+// handleMyChatMember purges chat when bot is removed from group or blocked
+func (ch *CommandHandler) handleMyChatMember(update *telegram.ChatMemberUpdated) {
+	if !update.NewChatMember.Left() {
+		return
+	}
+
+	log.Printf("Bot was removed from chat %d (%s)", update.Chat.ID, update.NewChatMember.Status)
+	ch.timerManager.PurgeChat(update.Chat.ID)
+}
+
+// resolveLocale picks chat locale override or sender's language
+func (ch *CommandHandler) resolveLocale(message *telegram.Message) i18n.Locale {
+	if locale, ok := ch.settings.Locale(message.Chat.ID); ok {
+		return locale
+	}
+
+	if message.From != nil {
+		return i18n.Resolve(message.From.LanguageCode)
 	}
+
+	return i18n.DefaultLocale
 }
 
 // parseCommand parses message text into command
@@ -77,104 +156,125 @@ func parseCommand(text string) *Command {
 }
 
 // handleTimerCommand processes /timer command
-func (ch *CommandHandler) handleTimerCommand(ctx context.Context, chatID int64, args string) {
+func (ch *CommandHandler) handleTimerCommand(ctx context.Context, chatID int64, locale i18n.Locale, args string) {
 	if args == "" {
-		ch.sendMessage(ctx, chatID, "Использование: /timer Xs или /timer Xm\nПример: /timer 30s или /timer 10m")
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgTimerUsage))
 		return
 	}
 
-	duration, durationText, err := parseTimerDuration(args)
+	duration, err := parseTimerDuration(args)
 	if err != nil {
-		ch.sendMessage(ctx, chatID, "Неверный формат времени. Используйте: /timer 30s или /timer 10m")
+		if durationErr, ok := err.(*DurationError); ok {
+			ch.sendMessage(ctx, chatID, locale.T(i18n.MsgInvalidToken, locale.T(durationErr.Reason), durationErr.Token, durationErr.Input))
+		} else {
+			ch.sendMessage(ctx, chatID, locale.T(i18n.MsgInvalidFormat))
+		}
 		return
 	}
 
-	if duration > 24*time.Hour {
-		ch.sendMessage(ctx, chatID, "Максимальное время таймера - 24 часа")
+	ch.startTimer(ctx, chatID, locale, Reminder{Duration: duration})
+}
+
+// handleReminderPhrase processes natural-language timer request
+func (ch *CommandHandler) handleReminderPhrase(ctx context.Context, chatID int64, locale i18n.Locale, text string) {
+	reminder, ok := parsePhrase(text)
+	if !ok {
 		return
 	}
 
-	err = ch.timerManager.SetTimer(ctx, chatID, duration, durationText)
-	if err != nil {
-		ch.sendMessage(ctx, chatID, "Ошибка при установке таймера. Попробуйте еще раз.")
+	log.Printf("Received timer phrase from chat %d: %s", chatID, reminder.Duration)
+	ch.startTimer(ctx, chatID, locale, reminder)
+}
+
+// startTimer validates duration against limits, sets timer and confirms it
+func (ch *CommandHandler) startTimer(ctx context.Context, chatID int64, locale i18n.Locale, reminder Reminder) {
+	if reminder.Duration > ch.limits.MaxDuration {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgMaxDuration, locale.Duration(ch.limits.MaxDuration)))
+		return
+	}
+
+	if reminder.Duration < ch.limits.MinDuration {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgMinDuration, locale.Duration(ch.limits.MinDuration)))
+		return
+	}
+
+	notification := locale.T(i18n.MsgTimeUp)
+	if reminder.Text != "" {
+		notification = locale.T(i18n.MsgReminder, reminder.Text)
+	}
+
+	err := ch.timerManager.SetTimer(ctx, chatID, reminder.Duration, notification)
+	switch {
+	case errors.Is(err, ErrChatTimerLimit):
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgChatTimerLimit, ch.limits.MaxTimersPerChat))
+		return
+	case errors.Is(err, ErrGlobalTimerLimit):
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgGlobalTimerLimit))
+		return
+	case err != nil:
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgSetFailed))
 		log.Printf("Failed to set timer for chat %d: %v", chatID, err)
 		return
 	}
 
-	message := fmt.Sprintf("Таймер на %s установлен.", durationText)
-	ch.sendMessage(ctx, chatID, message)
+	if reminder.Text != "" {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgReminderSet, locale.Duration(reminder.Duration), reminder.Text))
+	} else {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgTimerSet, locale.Duration(reminder.Duration)))
+	}
 }
 
 // handleCancelCommand processes /cancel command
-func (ch *CommandHandler) handleCancelCommand(ctx context.Context, chatID int64) {
+func (ch *CommandHandler) handleCancelCommand(ctx context.Context, chatID int64, locale i18n.Locale) {
 	if ch.timerManager.CancelTimer(chatID) {
-		ch.sendMessage(ctx, chatID, "Таймер отменён.")
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgTimerCancelled))
 	} else {
-		ch.sendMessage(ctx, chatID, "Активный таймер не найден.")
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgNoActiveTimer))
 	}
 }
 
-// sendUnknownCommandMessage sends message for unknown command
-func (ch *CommandHandler) sendUnknownCommandMessage(ctx context.Context, chatID int64) {
-	ch.sendMessage(ctx, chatID, "Неизвестная команда. Доступные команды:\n/timer Xs или /timer Xm - установить таймер\n/cancel - отменить таймер")
-}
-
-// sendMessage sends message with error logging
-func (ch *CommandHandler) sendMessage(ctx context.Context, chatID int64, text string) {
-	err := ch.telegram.SendMessage(ctx, chatID, text)
-	if err != nil {
-		log.Printf("Failed to send message to chat %d: %v", chatID, err)
+// handleStatusCommand processes /status command
+func (ch *CommandHandler) handleStatusCommand(ctx context.Context, chatID int64, locale i18n.Locale) {
+	remaining, ok := ch.timerManager.GetActiveTimerInfo(chatID)
+	if !ok {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgNoActiveTimer))
+		return
 	}
-}
 
-// parseTimerDuration parses timer duration string (e.g., "30s", "10m")
-func parseTimerDuration(input string) (time.Duration, string, error) {
-	// Regular expression to match number + unit
-	re := regexp.MustCompile(`^(\d+)([sm])$`)
-	matches := re.FindStringSubmatch(strings.ToLower(strings.TrimSpace(input)))
+	// Round up so that last fraction of a second is not shown as zero
+	remaining = (remaining + time.Second - 1).Truncate(time.Second)
+	ch.sendMessage(ctx, chatID, locale.T(i18n.MsgTimerStatus, locale.Duration(remaining)))
+}
 
-	if len(matches) != 3 {
-		return 0, "", fmt.Errorf("invalid format")
+// handleLangCommand processes /lang command
+func (ch *CommandHandler) handleLangCommand(ctx context.Context, chatID int64, locale i18n.Locale, args string) {
+	if args == "" {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgLangUsage, locale.Name()))
+		return
 	}
 
-	number, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return 0, "", fmt.Errorf("invalid number: %w", err)
+	newLocale, ok := i18n.Match(args)
+	if !ok {
+		ch.sendMessage(ctx, chatID, locale.T(i18n.MsgLangUnknown))
+		return
 	}
 
-	unit := matches[2]
+	ch.settings.SetLocale(chatID, newLocale)
+	ch.sendMessage(ctx, chatID, newLocale.T(i18n.MsgLangChanged))
+}
 
-	var duration time.Duration
-	var durationText string
+// sendUnknownCommandMessage sends message for unknown command
+func (ch *CommandHandler) sendUnknownCommandMessage(ctx context.Context, chatID int64, locale i18n.Locale) {
+	ch.sendMessage(ctx, chatID, locale.T(i18n.MsgUnknownCommand))
+}
 
-	switch unit {
-	case "s":
-		if number > 86400 { // Max 24 hours in seconds
-			return 0, "", fmt.Errorf("too many seconds")
-		}
-		duration = time.Duration(number) * time.Second
-		if number == 1 {
-			durationText = "1 секунду"
-		} else if number < 5 {
-			durationText = fmt.Sprintf("%d секунды", number)
-		} else {
-			durationText = fmt.Sprintf("%d секунд", number)
-		}
-	case "m":
-		if number > 1440 { // Max 24 hours in minutes
-			return 0, "", fmt.Errorf("too many minutes")
-		}
-		duration = time.Duration(number) * time.Minute
-		if number == 1 {
-			durationText = "1 минуту"
-		} else if number < 5 {
-			durationText = fmt.Sprintf("%d минуты", number)
-		} else {
-			durationText = fmt.Sprintf("%d минут", number)
+// sendMessage sends message with error logging
+func (ch *CommandHandler) sendMessage(ctx context.Context, chatID int64, text string) {
+	err := ch.telegram.SendMessage(ctx, chatID, text)
+	if err != nil {
+		log.Printf("Failed to send message to chat %d: %v", chatID, err)
+		if telegram.IsChatUnavailable(err) {
+			ch.timerManager.PurgeChat(chatID)
 		}
-	default:
-		return 0, "", fmt.Errorf("unsupported unit: %s", unit)
 	}
-
-	return duration, durationText, nil
 }
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"sync"
+
+	"tg-timer/pkg/telegram"
+)
+
+// Deduplicator passes update to handler only once. Telegram redelivers
+// update if response to webhook request is slow or lost, and getUpdates
+// returns updates again after restart until they are confirmed.
+//
+// Recently seen update IDs are kept in LRU window of bounded size. IDs not
+// greater than watermark and not older than window below it are considered
+// seen too: Dispatcher.Watermark is restored after restart, when window is
+// empty. ID further below watermark means Telegram started update IDs anew
+// after long inactivity, so watermark is dropped.
+type Deduplicator struct {
+	handler  UpdateHandler
+	size     int
+	observer Observer
+
+	mu    sync.Mutex
+	seen  map[int]*list.Element
+	order *list.List // update IDs, most recently seen first
+	floor int        // updates with ID <= floor were handled before restart
+}
+
+var _ UpdateHandler = (*Deduplicator)(nil)
+
+// NewDeduplicator creates deduplicator remembering size recent updates
+func NewDeduplicator(handler UpdateHandler, size int) *Deduplicator {
+	return &Deduplicator{
+		handler:  handler,
+		size:     size,
+		observer: nopObserver{},
+		seen:     make(map[int]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// SetObserver sets receiver of duplicate update events, must be called before
+// updates are handled
+func (d *Deduplicator) SetObserver(observer Observer) {
+	d.observer = observer
+}
+
+// Restore sets watermark saved before restart
+func (d *Deduplicator) Restore(mark int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.floor = mark
+}
+
+// HandleUpdate passes update to handler unless it was seen before
+func (d *Deduplicator) HandleUpdate(ctx context.Context, update telegram.Update) {
+	if !d.remember(update.UpdateID) {
+		d.observer.UpdateDuplicated()
+		log.Printf("Skipping duplicate update %d", update.UpdateID)
+		return
+	}
+
+	d.handler.HandleUpdate(ctx, update)
+}
+
+// remember records update ID, returns false if it was seen already
+func (d *Deduplicator) remember(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if id <= d.floor {
+		if id > d.floor-d.size {
+			return false
+		}
+		d.floor = 0
+	}
+	if elem, ok := d.seen[id]; ok {
+		d.order.MoveToFront(elem)
+		return false
+	}
+
+	d.seen[id] = d.order.PushFront(id)
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(int))
+	}
+	return true
+}
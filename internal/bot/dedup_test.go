@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeduplicatorSkipsSeenUpdates(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDeduplicator(h, 2)
+	ctx := context.Background()
+
+	// Duplicate of 1 refreshes it in window of size 2, so 3 evicts 2, and
+	// then 2 evicts 1
+	for _, id := range []int{1, 2, 1, 3, 2, 1} {
+		d.HandleUpdate(ctx, chatUpdate(id, 1))
+	}
+
+	want := []int{1, 2, 3, 2, 1}
+	ids := h.handled[1]
+	if len(ids) != len(want) {
+		t.Fatalf("expected updates %v to be handled, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("expected updates %v to be handled, got %v", want, ids)
+		}
+	}
+}
+
+func TestDeduplicatorRestoredMark(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDeduplicator(h, 10)
+	d.Restore(5)
+	ctx := context.Background()
+
+	for _, id := range []int{4, 5, 6} {
+		d.HandleUpdate(ctx, chatUpdate(id, 1))
+	}
+
+	if ids := h.handled[1]; len(ids) != 1 || ids[0] != 6 {
+		t.Fatalf("expected only update 6 to be handled, got %v", ids)
+	}
+}
+
+func TestDeduplicatorDropsMarkAfterIDsStartAnew(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDeduplicator(h, 10)
+	d.Restore(1000)
+	ctx := context.Background()
+
+	// 995 is within window below mark, 7 means update IDs started anew
+	for _, id := range []int{995, 7, 8, 995} {
+		d.HandleUpdate(ctx, chatUpdate(id, 1))
+	}
+
+	want := []int{7, 8, 995}
+	ids := h.handled[1]
+	if len(ids) != len(want) {
+		t.Fatalf("expected updates %v to be handled, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("expected updates %v to be handled, got %v", want, ids)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+)
+
+// ErrChatQueueFull is returned when chat has too many unprocessed updates
+var ErrChatQueueFull = errors.New("chat update queue is full")
+
+// ErrDispatcherStopped is returned when update arrives after Stop
+var ErrDispatcherStopped = errors.New("dispatcher is stopped")
+
+// UpdateHandler processes single update
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, update telegram.Update)
+}
+
+// chatUpdates is queue of chat's updates waiting for processing
+type chatUpdates struct {
+	updates []telegram.Update
+}
+
+// Dispatcher processes updates of each chat one by one in arrival order,
+// while different chats are processed concurrently by fixed number of workers
+type Dispatcher struct {
+	handler   UpdateHandler
+	queueSize int
+	window    int // restored floor covers only this many IDs below it
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	chats   map[int64]*chatUpdates // chats with queued or processing updates
+	ready   []int64                // chats with queued updates and no update in progress
+	pending map[int]int            // update ID -> number of its queued or processing copies
+	lastID  int                    // greatest dispatched update ID
+	floor   int                    // updates with ID <= floor were handled before restart
+	stopped bool
+	wg      sync.WaitGroup     // workers
+	cancel  context.CancelFunc // cancels handlers in progress
+}
+
+// NewDispatcher creates dispatcher and starts its workers. Updates are handled
+// with ctx, so cancelling it aborts handling.
+func NewDispatcher(ctx context.Context, handler UpdateHandler, cfg config.Updates) *Dispatcher {
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Dispatcher{
+		cancel:    cancel,
+		handler:   handler,
+		queueSize: cfg.ChatQueueSize,
+		window:    cfg.DedupWindow,
+		chats:     make(map[int64]*chatUpdates),
+		pending:   make(map[int]int),
+	}
+	d.cond = sync.NewCond(&d.mu)
+
+	for i := 0; i < cfg.Workers; i++ {
+		d.wg.Add(1)
+		go d.work(ctx)
+	}
+	return d
+}
+
+// Dispatch queues update for processing after previous updates of same chat
+func (d *Dispatcher) Dispatch(update telegram.Update) error {
+	chatID := updateChatID(update)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return ErrDispatcherStopped
+	}
+
+	chat, busy := d.chats[chatID]
+	if !busy {
+		chat = &chatUpdates{}
+		d.chats[chatID] = chat
+	}
+	if len(chat.updates) >= d.queueSize {
+		return ErrChatQueueFull
+	}
+
+	if d.floor > 0 && update.UpdateID <= d.floor-d.window {
+		// Telegram starts update IDs anew after long inactivity, so restored
+		// watermark no longer describes handled updates
+		log.Printf("Update %d is far below restored watermark %d, dropping watermark", update.UpdateID, d.floor)
+		d.floor = 0
+		d.lastID = 0
+	}
+
+	chat.updates = append(chat.updates, update)
+	d.pending[update.UpdateID]++
+	if update.UpdateID > d.lastID {
+		d.lastID = update.UpdateID
+	}
+	if !busy {
+		d.ready = append(d.ready, chatID)
+		d.cond.Signal()
+	}
+	return nil
+}
+
+// Restore sets watermark saved before restart
+func (d *Dispatcher) Restore(mark int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.floor = mark
+	if mark > d.lastID {
+		d.lastID = mark
+	}
+}
+
+// Watermark returns greatest update ID such that all dispatched updates with
+// IDs up to it are handled. Updates of different chats finish out of order,
+// so greater IDs may be handled already, but they are not covered until
+// earlier ones are handled too. Updates dropped or cancelled on shutdown are
+// not handled, so watermark stays below them.
+func (d *Dispatcher) Watermark() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	mark := d.lastID
+	for id := range d.pending {
+		if id-1 < mark {
+			mark = id - 1
+		}
+	}
+	if mark < d.floor {
+		mark = d.floor
+	}
+	return mark
+}
+
+// Stop stops accepting updates and waits until queued ones are processed
+func (d *Dispatcher) Stop() {
+	d.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting updates and waits until queued ones are processed
+// or ctx is done. After that updates still queued are dropped and handlers in
+// progress are cancelled.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopped = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	dropped := 0
+	for _, chat := range d.chats {
+		dropped += len(chat.updates)
+		chat.updates = nil
+	}
+	d.ready = nil
+	d.mu.Unlock()
+
+	d.cancel()
+	<-drained
+	log.Printf("Update processing interrupted, %d queued updates dropped", dropped)
+	return ctx.Err()
+}
+
+// work processes updates until dispatcher is stopped and queue is empty
+func (d *Dispatcher) work(ctx context.Context) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.stopped {
+			d.cond.Wait()
+		}
+		if len(d.ready) == 0 {
+			d.mu.Unlock()
+			return
+		}
+
+		chatID := d.ready[0]
+		d.ready = d.ready[1:]
+		chat := d.chats[chatID]
+		update := chat.updates[0]
+		chat.updates = chat.updates[1:]
+		d.mu.Unlock()
+
+		d.handle(ctx, update)
+
+		d.mu.Lock()
+		// Handler cancelled on shutdown may have not finished the update
+		if ctx.Err() == nil {
+			if d.pending[update.UpdateID]--; d.pending[update.UpdateID] == 0 {
+				delete(d.pending, update.UpdateID)
+			}
+		}
+		if len(chat.updates) > 0 {
+			// Other chats go first so busy chat doesn't starve them
+			d.ready = append(d.ready, chatID)
+			d.cond.Signal()
+		} else {
+			delete(d.chats, chatID)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// handle calls handler recovering from panic
+func (d *Dispatcher) handle(ctx context.Context, update telegram.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic in update handler: %v", r)
+		}
+	}()
+	d.handler.HandleUpdate(ctx, update)
+}
+
+// updateChatID returns ID of chat update belongs to, zero if unknown
+func updateChatID(update telegram.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID
+	}
+	return 0
+}
@@ -0,0 +1,232 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+)
+
+// recordingHandler records handled updates, blocking on chats in block
+type recordingHandler struct {
+	mu      sync.Mutex
+	handled map[int64][]int // chatID -> update IDs
+	block   map[int64]chan struct{}
+	started chan int
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{
+		handled: make(map[int64][]int),
+		block:   make(map[int64]chan struct{}),
+		started: make(chan int, 100),
+	}
+}
+
+func (h *recordingHandler) HandleUpdate(ctx context.Context, update telegram.Update) {
+	chatID := updateChatID(update)
+	h.started <- update.UpdateID
+
+	h.mu.Lock()
+	block := h.block[chatID]
+	h.mu.Unlock()
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	h.mu.Lock()
+	h.handled[chatID] = append(h.handled[chatID], update.UpdateID)
+	h.mu.Unlock()
+}
+
+func chatUpdate(updateID int, chatID int64) telegram.Update {
+	return telegram.Update{
+		UpdateID: updateID,
+		Message:  &telegram.Message{Chat: telegram.Chat{ID: chatID}},
+	}
+}
+
+func waitStarted(t *testing.T, h *recordingHandler, updateID int) {
+	t.Helper()
+
+	select {
+	case id := <-h.started:
+		if id != updateID {
+			t.Fatalf("expected update %d to start, got %d", updateID, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("update %d was not handled", updateID)
+	}
+}
+
+func TestDispatcherKeepsChatOrder(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 4, ChatQueueSize: 100})
+
+	for i := 1; i <= 50; i++ {
+		if err := d.Dispatch(chatUpdate(i, int64(i%2))); err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+	}
+	d.Stop()
+
+	for chatID, ids := range h.handled {
+		if len(ids) != 25 {
+			t.Fatalf("expected 25 updates in chat %d, got %d", chatID, len(ids))
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Fatalf("chat %d updates handled out of order: %v", chatID, ids)
+			}
+		}
+	}
+}
+
+func TestDispatcherChatsAreConcurrent(t *testing.T) {
+	h := newRecordingHandler()
+	unblock := make(chan struct{})
+	h.block[1] = unblock
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 2, ChatQueueSize: 1})
+
+	if err := d.Dispatch(chatUpdate(1, 1)); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	waitStarted(t, h, 1)
+
+	// Busy chat queues one more update and rejects next
+	if err := d.Dispatch(chatUpdate(2, 1)); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if err := d.Dispatch(chatUpdate(3, 1)); err != ErrChatQueueFull {
+		t.Fatalf("expected ErrChatQueueFull, got %v", err)
+	}
+
+	// Other chat is not blocked by busy one
+	if err := d.Dispatch(chatUpdate(4, 2)); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	waitStarted(t, h, 4)
+
+	close(unblock)
+	d.Stop()
+
+	if err := d.Dispatch(chatUpdate(5, 2)); err != ErrDispatcherStopped {
+		t.Fatalf("expected ErrDispatcherStopped, got %v", err)
+	}
+	if ids := h.handled[1]; len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("unexpected updates handled in chat 1: %v", ids)
+	}
+}
+
+func TestDispatcherShutdownDeadline(t *testing.T) {
+	h := newRecordingHandler()
+	h.block[1] = make(chan struct{})
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 1, ChatQueueSize: 10})
+
+	for i := 1; i <= 3; i++ {
+		if err := d.Dispatch(chatUpdate(i, 1)); err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+	}
+	waitStarted(t, h, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// Blocked handler was cancelled and queued updates were dropped
+	select {
+	case id := <-h.started:
+		t.Fatalf("unexpected update %d handled after deadline", id)
+	default:
+	}
+	if ids := h.handled[1]; len(ids) != 0 {
+		t.Fatalf("expected no updates to complete, got %v", ids)
+	}
+}
+
+func TestDispatcherWatermark(t *testing.T) {
+	h := newRecordingHandler()
+	h.block[1] = make(chan struct{})
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 2, ChatQueueSize: 10})
+	d.Restore(10)
+
+	if mark := d.Watermark(); mark != 10 {
+		t.Fatalf("expected restored watermark 10, got %d", mark)
+	}
+
+	// Update 11 is stuck, so handled 13 is not covered
+	for _, update := range []telegram.Update{chatUpdate(11, 1), chatUpdate(12, 1), chatUpdate(13, 2)} {
+		if err := d.Dispatch(update); err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+	}
+	waitStarted(t, h, 11)
+	waitStarted(t, h, 13)
+	deadline := time.Now().Add(time.Second)
+	for {
+		h.mu.Lock()
+		done := len(h.handled[2]) == 1
+		h.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("update 13 was not handled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if mark := d.Watermark(); mark != 10 {
+		t.Fatalf("expected watermark 10 while update 11 is in progress, got %d", mark)
+	}
+
+	// Cancelled 11 and dropped 12 are redelivered after restart
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	d.Shutdown(ctx)
+	if mark := d.Watermark(); mark != 10 {
+		t.Fatalf("expected watermark 10 after interrupted shutdown, got %d", mark)
+	}
+}
+
+func TestDispatcherWatermarkAfterDrain(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 4, ChatQueueSize: 10})
+
+	for i := 1; i <= 20; i++ {
+		if err := d.Dispatch(chatUpdate(i, int64(i%5))); err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+	}
+	d.Stop()
+
+	if mark := d.Watermark(); mark != 20 {
+		t.Fatalf("expected watermark 20 after all updates are handled, got %d", mark)
+	}
+}
+
+func TestDispatcherDropsWatermarkAfterIDsStartAnew(t *testing.T) {
+	h := newRecordingHandler()
+	d := NewDispatcher(context.Background(), h, config.Updates{Workers: 2, ChatQueueSize: 10, DedupWindow: 100})
+	d.Restore(10000)
+
+	for _, id := range []int{5, 6} {
+		if err := d.Dispatch(chatUpdate(id, 1)); err != nil {
+			t.Fatalf("Dispatch failed: %v", err)
+		}
+	}
+	d.Stop()
+
+	if mark := d.Watermark(); mark != 6 {
+		t.Fatalf("expected watermark 6 after update IDs started anew, got %d", mark)
+	}
+}
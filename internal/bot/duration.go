@@ -0,0 +1,238 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"tg-timer/internal/i18n"
+)
+
+// DurationError describes unparseable token of duration input
+type DurationError struct {
+	Input  string
+	Offset int    // byte offset of Token in Input
+	Token  string // unparseable part of Input
+	Reason i18n.Key
+}
+
+func (e *DurationError) Error() string {
+	return fmt.Sprintf("invalid duration %q: %s at offset %d (%q)", e.Input, i18n.English.T(e.Reason), e.Offset, e.Token)
+}
+
+// goUnits lists units accepted by time.ParseDuration
+var goUnits = map[string]bool{
+	"ns": true, "us": true, "µs": true, "μs": true, "ms": true, "s": true, "m": true, "h": true,
+}
+
+// isoDesignators lists ISO 8601 duration designators in required order
+var isoDesignators = []struct {
+	designator byte
+	timePart   bool
+	unit       time.Duration
+}{
+	{'Y', false, 0}, // calendar-dependent, not supported
+	{'M', false, 0}, // calendar-dependent, not supported
+	{'W', false, 7 * 24 * time.Hour},
+	{'D', false, 24 * time.Hour},
+	{'H', true, time.Hour},
+	{'M', true, time.Minute},
+	{'S', true, time.Second},
+}
+
+// parseTimerDuration parses timer duration in Go style ("30s", "10m", "1h30m0s")
+// or ISO 8601 format ("PT1H30M", "P1DT2H")
+func parseTimerDuration(input string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(input)
+	offset := strings.Index(input, trimmed)
+
+	var duration time.Duration
+	var err error
+	if strings.HasPrefix(trimmed, "P") || strings.HasPrefix(trimmed, "p") {
+		duration, err = parseISODuration(trimmed)
+	} else {
+		duration, err = parseGoDuration(trimmed)
+	}
+
+	if err != nil {
+		if durationErr, ok := err.(*DurationError); ok {
+			// Report position in original, untrimmed input
+			durationErr.Input = input
+			durationErr.Offset += offset
+		}
+		return 0, err
+	}
+
+	if duration <= 0 {
+		return 0, &DurationError{Input: input, Offset: offset, Token: trimmed, Reason: i18n.MsgErrNotPositive}
+	}
+
+	return duration, nil
+}
+
+// parseGoDuration parses duration compatible with time.ParseDuration;
+// whitespace between components is allowed ("1h 30m")
+func parseGoDuration(input string) (time.Duration, error) {
+	if input == "" {
+		return 0, &DurationError{Input: input, Reason: i18n.MsgErrEmpty}
+	}
+
+	var compact strings.Builder
+	i := 0
+	for i < len(input) {
+		if input[i] == ' ' || input[i] == '\t' {
+			i++
+			continue
+		}
+
+		if input[i] == '-' || input[i] == '+' {
+			return 0, &DurationError{Input: input, Offset: i, Token: input[i : i+1], Reason: i18n.MsgErrSign}
+		}
+
+		// Number: digits with optional fraction
+		start := i
+		for i < len(input) && (isDigit(input[i]) || input[i] == '.') {
+			i++
+		}
+		number := input[start:i]
+		if number == "" || number == "." || strings.Count(number, ".") > 1 {
+			end := tokenEnd(input, start)
+			return 0, &DurationError{Input: input, Offset: start, Token: input[start:end], Reason: i18n.MsgErrNumber}
+		}
+
+		// Unit: letters up to next digit or space
+		unitStart := i
+		for i < len(input) && !isDigit(input[i]) && input[i] != '.' && input[i] != ' ' && input[i] != '\t' {
+			i++
+		}
+		unit := strings.ToLower(input[unitStart:i])
+		if unit == "" {
+			return 0, &DurationError{Input: input, Offset: start, Token: number, Reason: i18n.MsgErrMissingUnit}
+		}
+		if !goUnits[unit] {
+			return 0, &DurationError{Input: input, Offset: unitStart, Token: input[unitStart:i], Reason: i18n.MsgErrUnknownUnit}
+		}
+
+		compact.WriteString(number)
+		compact.WriteString(unit)
+	}
+
+	duration, err := time.ParseDuration(compact.String())
+	if err != nil {
+		// Components are valid, so only overflow is left
+		return 0, &DurationError{Input: input, Token: input, Reason: i18n.MsgErrTooLarge}
+	}
+	return duration, nil
+}
+
+// parseISODuration parses ISO 8601 duration (e.g., "PT1H30M", "P1W", "PT0.5S");
+// years and months are rejected as calendar-dependent
+func parseISODuration(input string) (time.Duration, error) {
+	s := strings.Map(asciiUpper, input) // keeps byte offsets of input
+	i := 1                              // skip "P"
+	next := 0
+	timePart := false
+	components := 0
+	fractional := false
+	var total float64
+
+	for i < len(s) {
+		if s[i] == 'T' {
+			if timePart {
+				return 0, &DurationError{Input: input, Offset: i, Token: input[i : i+1], Reason: i18n.MsgErrUnexpected}
+			}
+			timePart = true
+			i++
+			if i == len(s) {
+				return 0, &DurationError{Input: input, Offset: i - 1, Token: input[i-1:], Reason: i18n.MsgErrMissingNumber}
+			}
+			continue
+		}
+
+		if fractional {
+			// Only the smallest component may have fraction
+			return 0, &DurationError{Input: input, Offset: i, Token: input[i:tokenEnd(s, i)], Reason: i18n.MsgErrUnexpected}
+		}
+
+		start := i
+		for i < len(s) && (isDigit(s[i]) || s[i] == '.' || s[i] == ',') {
+			i++
+		}
+		number := strings.Replace(s[start:i], ",", ".", 1)
+		if number == "" {
+			return 0, &DurationError{Input: input, Offset: start, Token: input[start:runeEnd(input, start)], Reason: i18n.MsgErrMissingNumber}
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, &DurationError{Input: input, Offset: start, Token: input[start:i], Reason: i18n.MsgErrNumber}
+		}
+		if i == len(s) {
+			return 0, &DurationError{Input: input, Offset: start, Token: input[start:i], Reason: i18n.MsgErrMissingUnit}
+		}
+
+		// Designators must follow in order: Y M W D T H M S
+		found := false
+		for next < len(isoDesignators) {
+			d := isoDesignators[next]
+			next++
+			if d.designator == s[i] && d.timePart == timePart {
+				if d.unit == 0 {
+					return 0, &DurationError{Input: input, Offset: start, Token: input[start : i+1], Reason: i18n.MsgErrCalendarUnit}
+				}
+				total += value * float64(d.unit)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, &DurationError{Input: input, Offset: i, Token: input[i:tokenEnd(s, i)], Reason: i18n.MsgErrUnexpected}
+		}
+
+		fractional = strings.Contains(number, ".")
+		components++
+		i++
+	}
+
+	if components == 0 {
+		return 0, &DurationError{Input: input, Token: input, Reason: i18n.MsgErrMissingNumber}
+	}
+	if total >= math.MaxInt64 { // float64(math.MaxInt64) is 2^63, which overflows Duration
+		return 0, &DurationError{Input: input, Token: input, Reason: i18n.MsgErrTooLarge}
+	}
+
+	return time.Duration(total), nil
+}
+
+// tokenEnd returns end of token starting at i (up to whitespace)
+func tokenEnd(s string, i int) int {
+	end := strings.IndexFunc(s[i:], unicode.IsSpace)
+	if end < 0 {
+		return len(s)
+	}
+	if end == 0 {
+		return runeEnd(s, i)
+	}
+	return i + end
+}
+
+// runeEnd returns end of rune starting at i, so tokens don't cut multi-byte
+// characters
+func runeEnd(s string, i int) int {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return i + size
+}
+
+func asciiUpper(r rune) rune {
+	if r >= 'a' && r <= 'z' {
+		return r - 'a' + 'A'
+	}
+	return r
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tg-timer/internal/i18n"
+)
+
+func TestParseTimerDuration(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"30s":                      30 * time.Second,
+		"1h30m":                    90 * time.Minute,
+		"1h 30m":                   90 * time.Minute,
+		" 1.5h ":                   90 * time.Minute,
+		"PT1H30M":                  90 * time.Minute,
+		"pt1h30m":                  90 * time.Minute,
+		"P1DT2H":                   26 * time.Hour,
+		"P1W":                      7 * 24 * time.Hour,
+		"PT0,5S":                   500 * time.Millisecond,
+		"PT2562047H":               2562047 * time.Hour,
+		"2562047h47m16.854775807s": time.Duration(1<<63 - 1),
+	} {
+		got, err := parseTimerDuration(input)
+		if err != nil {
+			t.Errorf("parseTimerDuration(%q) failed: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseTimerDuration(%q) = %v, expected %v", input, got, want)
+		}
+	}
+}
+
+func TestParseTimerDurationErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		token  string
+		reason i18n.Key
+	}{
+		// Go style
+		{"", 0, "", i18n.MsgErrEmpty},
+		{"-5m", 0, "-", i18n.MsgErrSign},
+		{"1h-5m", 1, "h-", i18n.MsgErrUnknownUnit},
+		{"1..5m", 0, "1..5m", i18n.MsgErrNumber},
+		{"m5", 0, "m5", i18n.MsgErrNumber},
+		{"1h 30", 3, "30", i18n.MsgErrMissingUnit},
+		{"1h30x", 4, "x", i18n.MsgErrUnknownUnit},
+		{"5d", 1, "d", i18n.MsgErrUnknownUnit},
+		{"5ч", 1, "ч", i18n.MsgErrUnknownUnit},
+		{"мин", 0, "мин", i18n.MsgErrNumber},
+		{"  5x", 3, "x", i18n.MsgErrUnknownUnit},
+		{"3000000h", 0, "3000000h", i18n.MsgErrTooLarge},
+		{"0s", 0, "0s", i18n.MsgErrNotPositive},
+
+		// ISO 8601
+		{"P", 0, "P", i18n.MsgErrMissingNumber},
+		{"PT", 1, "T", i18n.MsgErrMissingNumber},
+		{"PTT", 2, "T", i18n.MsgErrUnexpected},
+		{"PTH", 2, "H", i18n.MsgErrMissingNumber},
+		{"PTч", 2, "ч", i18n.MsgErrMissingNumber},
+		{"PT1Hч", 4, "ч", i18n.MsgErrMissingNumber},
+		{"PT5", 2, "5", i18n.MsgErrMissingUnit},
+		{"PT1..5S", 2, "1..5", i18n.MsgErrNumber},
+		{"P1Y", 1, "1Y", i18n.MsgErrCalendarUnit},
+		{"p1m", 1, "1m", i18n.MsgErrCalendarUnit},
+		{"P1H", 2, "H", i18n.MsgErrUnexpected},
+		{"PT1M1H", 5, "H", i18n.MsgErrUnexpected},
+		{"PT1.5M30S", 6, "30S", i18n.MsgErrUnexpected},
+		{" PT5", 3, "5", i18n.MsgErrMissingUnit},
+		{"PT2562047H47M16.854775808S", 0, "PT2562047H47M16.854775808S", i18n.MsgErrTooLarge},
+		{"P99999999999W", 0, "P99999999999W", i18n.MsgErrTooLarge},
+		{"PT0S", 0, "PT0S", i18n.MsgErrNotPositive},
+	}
+
+	for _, tt := range tests {
+		_, err := parseTimerDuration(tt.input)
+
+		var durationErr *DurationError
+		if !errors.As(err, &durationErr) {
+			t.Errorf("parseTimerDuration(%q): expected DurationError, got %v", tt.input, err)
+			continue
+		}
+		if durationErr.Input != tt.input || durationErr.Offset != tt.offset || durationErr.Token != tt.token || durationErr.Reason != tt.reason {
+			t.Errorf("parseTimerDuration(%q) = %s at %d (%q), expected %s at %d (%q)",
+				tt.input, durationErr.Reason, durationErr.Offset, durationErr.Token, tt.reason, tt.offset, tt.token)
+		}
+	}
+}
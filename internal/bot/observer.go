@@ -0,0 +1,27 @@
+package bot
+
+import "time"
+
+// Observer receives bot events, e.g. to collect metrics. Methods are called
+// synchronously and must be fast.
+type Observer interface {
+	// UpdateReceived is called for every update: "message", "my_chat_member" or "other"
+	UpdateReceived(kind string)
+	// UpdateDuplicated is called when update is skipped as already handled
+	UpdateDuplicated()
+	// CommandReceived is called for every command, unknown ones are reported as "unknown"
+	CommandReceived(name string)
+	// TimerFired is called when timer completes later than its deadline by lateness
+	TimerFired(lateness time.Duration)
+	// TimersCancelled is called when n timers are cancelled, replaced or purged
+	TimersCancelled(n int)
+}
+
+// nopObserver ignores all events
+type nopObserver struct{}
+
+func (nopObserver) UpdateReceived(string)    {}
+func (nopObserver) UpdateDuplicated()        {}
+func (nopObserver) CommandReceived(string)   {}
+func (nopObserver) TimerFired(time.Duration) {}
+func (nopObserver) TimersCancelled(int)      {}
@@ -0,0 +1,332 @@
+package bot
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// phraseTokenRe splits text into words and numbers ("5мин" -> "5", "мин",
+// "1,5 часа" -> "1,5", "часа")
+var phraseTokenRe = regexp.MustCompile(`\p{N}+(?:[.,]\p{N}+)?|\p{L}+`)
+
+// leadWords are skipped at the beginning of phrase; true marks explicit timer request
+var leadWords = map[string]bool{
+	"напомни": true, "напомните": true, "напомнить": true, "поставь": true,
+	"поставьте": true, "заведи": true, "таймер": true, "мне": false, "пожалуйста": false,
+	"remind": true, "set": true, "start": true, "timer": true,
+	"me": false, "a": false, "please": false,
+}
+
+// prepositions introduce duration ("через 5 минут", "in 5 minutes")
+var prepositions = map[string]bool{
+	"через": true, "на": true,
+	"in": true, "after": true, "for": true,
+}
+
+// connectors join duration parts ("час и двадцать минут", "an hour and ten minutes")
+var connectors = map[string]bool{
+	"и":   true,
+	"and": true,
+}
+
+// textPrefixes are stripped from the beginning of reminder text
+var textPrefixes = map[string]bool{
+	"чтобы": true, "что": true, "про": true, "и": true,
+	"to": true, "that": true, "about": true, "and": true,
+}
+
+// unitWords maps unit words to durations
+var unitWords = map[string]time.Duration{
+	"секунда": time.Second, "секунду": time.Second, "секунды": time.Second, "секунд": time.Second, "сек": time.Second,
+	"минута": time.Minute, "минуту": time.Minute, "минуты": time.Minute, "минут": time.Minute, "мин": time.Minute,
+	"час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+	"день": day, "дня": day, "дней": day, "сутки": day, "суток": day,
+	"second": time.Second, "seconds": time.Second, "sec": time.Second, "secs": time.Second,
+	"minute": time.Minute, "minutes": time.Minute, "min": time.Minute, "mins": time.Minute,
+	"hour": time.Hour, "hours": time.Hour, "hr": time.Hour, "hrs": time.Hour,
+	"day": day, "days": day,
+}
+
+// day is not calendar day, timers don't follow daylight saving changes
+const day = 24 * time.Hour
+
+// smallerUnits maps unit to unit of bare trailing number ("час двадцать")
+var smallerUnits = map[time.Duration]time.Duration{
+	day:         time.Hour,
+	time.Hour:   time.Minute,
+	time.Minute: time.Second,
+}
+
+// unitLetters maps short unit letters, only accepted right after a number ("5м", "10s")
+var unitLetters = map[string]time.Duration{
+	"с": time.Second, "м": time.Minute, "ч": time.Hour, "д": day,
+	"s": time.Second, "m": time.Minute, "h": time.Hour, "d": day,
+}
+
+// numberWords maps number words to values
+var numberWords = map[string]float64{
+	"ноль": 0, "один": 1, "одна": 1, "одну": 1, "два": 2, "две": 2, "три": 3, "четыре": 4,
+	"пять": 5, "шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10,
+	"одиннадцать": 11, "двенадцать": 12, "тринадцать": 13, "четырнадцать": 14, "пятнадцать": 15,
+	"шестнадцать": 16, "семнадцать": 17, "восемнадцать": 18, "девятнадцать": 19,
+	"двадцать": 20, "тридцать": 30, "сорок": 40, "пятьдесят": 50,
+	"полтора": 1.5, "полторы": 1.5, "пол": 0.5,
+	"zero": 0, "one": 1, "an": 1, "two": 2, "three": 3, "four": 4,
+	"five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "half": 0.5,
+}
+
+// halfWords add half of previous unit ("час с половиной", "an hour and a half")
+var halfWords = map[string]bool{
+	"половиной": true, "half": true,
+}
+
+// phraseToken is a word of the phrase with its position in original text
+type phraseToken struct {
+	word       string // lower-cased
+	start, end int    // byte offsets in original text
+}
+
+// phraseParser parses tokens of single phrase
+type phraseParser struct {
+	text   string
+	tokens []phraseToken
+}
+
+// parsePhrase parses natural-language timer request, e.g. "через 5 минут",
+// "полчаса", "через час двадцать" or "напомни через 10 минут позвонить маме"
+func parsePhrase(text string) (Reminder, bool) {
+	p := &phraseParser{text: text}
+	for _, loc := range phraseTokenRe.FindAllStringIndex(text, -1) {
+		p.tokens = append(p.tokens, phraseToken{
+			word:  strings.ToLower(text[loc[0]:loc[1]]),
+			start: loc[0],
+			end:   loc[1],
+		})
+	}
+
+	// Skip lead words ("напомни мне", "remind me", "set a timer")
+	lead := 0
+	explicit := false
+	for lead < len(p.tokens) {
+		isExplicit, ok := leadWords[p.tokens[lead].word]
+		if !ok {
+			break
+		}
+		explicit = explicit || isExplicit
+		lead++
+	}
+
+	for i := lead; i < len(p.tokens); i++ {
+		// Without explicit request duration must open the phrase, otherwise
+		// ordinary messages like "я ушёл на 2 часа" would arm timers
+		if !explicit && i > lead {
+			break
+		}
+
+		from := i
+		if prepositions[p.tokens[i].word] {
+			from = i + 1
+		} else if i != lead {
+			continue
+		}
+
+		duration, end, ok := p.parseDuration(from)
+		if !ok || duration <= 0 {
+			continue
+		}
+
+		reminderText := p.reminderText(lead, i, end)
+
+		// Bare duration without preposition or explicit request must be the whole phrase
+		if from == i && !explicit && reminderText != "" {
+			return Reminder{}, false
+		}
+
+		return Reminder{Duration: duration, Text: reminderText}, true
+	}
+
+	return Reminder{}, false
+}
+
+// parseDuration parses duration starting at token i, returns index after it.
+// Durations not fitting time.Duration are rejected.
+func (p *phraseParser) parseDuration(i int) (time.Duration, int, bool) {
+	var total float64 // nanoseconds, checked for overflow before conversion
+	var lastUnit time.Duration
+	end := i
+
+	for i < len(p.tokens) {
+		number, next, hasNumber := p.parseNumber(i)
+		unit, next, hasUnit := p.parseUnit(next, hasNumber)
+
+		if !hasUnit {
+			// Bare trailing number takes next smaller unit ("час двадцать")
+			if smaller := smallerUnits[lastUnit]; hasNumber && smaller > 0 {
+				total += number * float64(smaller)
+				end = next
+			}
+			break
+		}
+
+		if !hasNumber {
+			number = 1
+		}
+		total += number * float64(unit)
+		lastUnit = unit
+		i = next
+		end = next
+
+		// "с половиной", "and a half"
+		if half, ok := p.parseHalf(i); ok {
+			total += float64(unit / 2)
+			i = half
+			end = half
+		}
+
+		// Skip connector only if another part follows
+		if i < len(p.tokens) && connectors[p.tokens[i].word] {
+			i++
+		}
+	}
+
+	if lastUnit == 0 || total >= math.MaxInt64 {
+		return 0, 0, false
+	}
+	return time.Duration(total), end, true
+}
+
+// parseNumber parses number at token i ("5", "двадцать пять", "полчаса")
+func (p *phraseParser) parseNumber(i int) (float64, int, bool) {
+	if i >= len(p.tokens) {
+		return 0, i, false
+	}
+
+	word := p.tokens[i].word
+	if unicode.IsDigit([]rune(word)[0]) {
+		// "1,5" and "1.5"; digits of other scripts are not parsed
+		n, err := strconv.ParseFloat(strings.Replace(word, ",", ".", 1), 64)
+		if err != nil {
+			return 0, i, false
+		}
+		return n, i + 1, true
+	}
+
+	// "полчаса", "полминуты": split "пол" prefix from unit word
+	if strings.HasPrefix(word, "пол") {
+		if _, ok := unitWords[strings.TrimPrefix(word, "пол")]; ok {
+			return 0.5, i, true
+		}
+	}
+
+	// "a minute", "an hour"
+	if word == "a" && i+1 < len(p.tokens) {
+		if _, ok := unitWords[p.tokens[i+1].word]; ok {
+			return 1, i + 1, true
+		}
+	}
+
+	n, ok := numberWords[word]
+	if !ok {
+		return 0, i, false
+	}
+
+	// Compound numbers: "двадцать пять", "twenty five"
+	if n >= 20 && int(n)%10 == 0 && i+1 < len(p.tokens) {
+		if units, ok := numberWords[p.tokens[i+1].word]; ok && units >= 1 && units <= 9 && units == float64(int(units)) {
+			return n + units, i + 2, true
+		}
+	}
+
+	// "half an hour": skip article
+	if word == "half" && i+1 < len(p.tokens) && (p.tokens[i+1].word == "an" || p.tokens[i+1].word == "a") {
+		return n, i + 2, true
+	}
+
+	return n, i + 1, true
+}
+
+// parseUnit parses unit at token i; short letters require preceding number
+func (p *phraseParser) parseUnit(i int, afterNumber bool) (time.Duration, int, bool) {
+	if i >= len(p.tokens) {
+		return 0, i, false
+	}
+
+	word := p.tokens[i].word
+	if unit, ok := unitWords[word]; ok {
+		return unit, i + 1, true
+	}
+
+	// "полчаса" was counted as number, unit is the rest of the word
+	if unit, ok := unitWords[strings.TrimPrefix(word, "пол")]; ok && strings.HasPrefix(word, "пол") {
+		return unit, i + 1, true
+	}
+
+	if unit, ok := unitLetters[word]; ok && afterNumber {
+		return unit, i + 1, true
+	}
+
+	return 0, i, false
+}
+
+// parseHalf parses "с половиной" or "and a half" at token i
+func (p *phraseParser) parseHalf(i int) (int, bool) {
+	if i+1 >= len(p.tokens) {
+		return i, false
+	}
+	if word := p.tokens[i].word; word != "с" && !connectors[word] {
+		return i, false
+	}
+
+	next := i + 1
+	if p.tokens[next].word == "a" && next+1 < len(p.tokens) {
+		next++
+	}
+
+	if halfWords[p.tokens[next].word] {
+		return next + 1, true
+	}
+	return i, false
+}
+
+// reminderText returns phrase text outside of lead words and duration
+func (p *phraseParser) reminderText(lead, durationStart, durationEnd int) string {
+	var parts []string
+
+	if before := p.span(lead, durationStart); before != "" {
+		parts = append(parts, before)
+	}
+
+	after := durationEnd
+	for after < len(p.tokens) && textPrefixes[p.tokens[after].word] {
+		after++
+	}
+	if rest := p.span(after, len(p.tokens)); rest != "" {
+		parts = append(parts, rest)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// span returns original text between tokens [from, to)
+func (p *phraseParser) span(from, to int) string {
+	if from >= to {
+		return ""
+	}
+
+	end := p.tokens[to-1].end
+	if to == len(p.tokens) {
+		// Keep trailing punctuation ("позвонить маме!"), trimmed below
+		end = len(p.text)
+	}
+
+	return strings.TrimRightFunc(p.text[p.tokens[from].start:end], func(r rune) bool {
+		return unicode.IsSpace(r) || r == '.' || r == ','
+	})
+}
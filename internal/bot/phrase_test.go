@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePhrase(t *testing.T) {
+	tests := []struct {
+		text     string
+		duration time.Duration
+		reminder string
+	}{
+		{"через 5 минут", 5 * time.Minute, ""},
+		{"полчаса", 30 * time.Minute, ""},
+		{"5мин", 5 * time.Minute, ""},
+		{"через час двадцать", 80 * time.Minute, ""},
+		{"через полтора часа", 90 * time.Minute, ""},
+		{"через час с половиной", 90 * time.Minute, ""},
+		{"через двадцать пять минут", 25 * time.Minute, ""},
+		{"через 10 минут позвонить маме", 10 * time.Minute, "позвонить маме"},
+		{"пожалуйста через 2 минуты", 2 * time.Minute, ""},
+		{"напомни через 10 минут позвонить маме", 10 * time.Minute, "позвонить маме"},
+		{"напомни мне позвонить маме через 10 минут", 10 * time.Minute, "позвонить маме"},
+		{"напомни через 5 минут, чтобы выключить плиту!", 5 * time.Minute, "выключить плиту!"},
+		{"поставь таймер на 3 минуты", 3 * time.Minute, ""},
+		{"in 5 minutes", 5 * time.Minute, ""},
+		{"half an hour", 30 * time.Minute, ""},
+		{"in an hour and a half", 90 * time.Minute, ""},
+		{"remind me in 10 minutes to call mom", 10 * time.Minute, "call mom"},
+		{"remind me to call mom in 10 minutes", 10 * time.Minute, "to call mom"},
+		{"set a timer for 90 seconds", 90 * time.Second, ""},
+		{"через 1,5 часа", 90 * time.Minute, ""},
+		{"через 2.5 минуты", 150 * time.Second, ""},
+		{"через 2 дня", 48 * time.Hour, ""},
+		{"через день полить цветы", 24 * time.Hour, "полить цветы"},
+		{"полдня", 12 * time.Hour, ""},
+		{"3д", 72 * time.Hour, ""},
+		{"через день двенадцать", 36 * time.Hour, ""},
+		{"remind me in 2 days to pay rent", 48 * time.Hour, "pay rent"},
+	}
+
+	for _, tt := range tests {
+		reminder, ok := parsePhrase(tt.text)
+		if !ok {
+			t.Errorf("parsePhrase(%q) was rejected", tt.text)
+			continue
+		}
+		if reminder.Duration != tt.duration || reminder.Text != tt.reminder {
+			t.Errorf("parsePhrase(%q) = %v %q, expected %v %q", tt.text, reminder.Duration, reminder.Text, tt.duration, tt.reminder)
+		}
+	}
+}
+
+func TestParsePhraseRejects(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"привет",
+		"я ушёл на 2 часа",
+		"буду через 5 минут",
+		"5 минут осталось",
+		"напомни позвонить маме",
+		"через пять",
+		"I will call you in 5 minutes",
+		"see you in an hour",
+		"10 minutes late",
+		"remind me",
+		"через 9223372036854775807 часов",
+		"через 9223372036854775807 часов 9223372036854775807 часов 5 минут",
+		"через 106752 дня",
+		"через 99999999999999999999999 секунд",
+	} {
+		if reminder, ok := parsePhrase(text); ok {
+			t.Errorf("parsePhrase(%q) = %v %q, expected rejection", text, reminder.Duration, reminder.Text)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"tg-timer/internal/clock"
+)
+
+// schedulerEntry represents callback scheduled for deadline
+type schedulerEntry struct {
+	deadline time.Time
+	index    int // index in heap, -1 when not scheduled
+	fire     func()
+}
+
+// entryHeap is min-heap of entries ordered by deadline
+type entryHeap []*schedulerEntry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	entry := x.(*schedulerEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[:n-1]
+	return entry
+}
+
+// scheduler runs callbacks at their deadlines using single goroutine and
+// min-heap keyed by deadline. Schedule, cancel and reschedule are O(log n).
+type scheduler struct {
+	entries entryHeap
+	clock   clock.Clock
+	mu      sync.Mutex
+	wake    chan struct{}
+	stopCh  chan struct{}
+	done    chan struct{}
+	firing  sync.WaitGroup // callbacks being run
+}
+
+// newScheduler creates scheduler and starts its loop
+func newScheduler(clk clock.Clock) *scheduler {
+	s := &scheduler{
+		clock:  clk,
+		wake:   make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// schedule schedules fire to run in its own goroutine at deadline
+func (s *scheduler) schedule(deadline time.Time, fire func()) *schedulerEntry {
+	entry := &schedulerEntry{deadline: deadline, fire: fire}
+
+	s.mu.Lock()
+	heap.Push(&s.entries, entry)
+	isNext := entry.index == 0
+	s.mu.Unlock()
+
+	if isNext {
+		s.notify()
+	}
+	return entry
+}
+
+// cancel removes entry from schedule, returns false if entry already fired
+// or was cancelled
+func (s *scheduler) cancel(entry *schedulerEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.index < 0 {
+		return false
+	}
+	heap.Remove(&s.entries, entry.index)
+	return true
+}
+
+// reschedule moves entry to new deadline, returns false if entry already
+// fired or was cancelled
+func (s *scheduler) reschedule(entry *schedulerEntry, deadline time.Time) bool {
+	s.mu.Lock()
+	if entry.index < 0 {
+		s.mu.Unlock()
+		return false
+	}
+	entry.deadline = deadline
+	heap.Fix(&s.entries, entry.index)
+	isNext := entry.index == 0
+	s.mu.Unlock()
+
+	if isNext {
+		s.notify()
+	}
+	return true
+}
+
+// len returns number of scheduled entries
+func (s *scheduler) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// stop stops scheduler loop and drops all scheduled entries
+func (s *scheduler) stop() {
+	select {
+	case <-s.stopCh:
+		return
+	default:
+		close(s.stopCh)
+	}
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		entry.index = -1
+	}
+	s.entries = nil
+}
+
+// wait waits until fired callbacks return, scheduler must be stopped
+func (s *scheduler) wait() {
+	s.firing.Wait()
+}
+
+// notify wakes scheduler loop to recalculate next deadline
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run fires due entries and sleeps until next deadline
+func (s *scheduler) run() {
+	defer close(s.done)
+
+	timer := s.clock.NewTimer(time.Hour)
+	stopTimer(timer)
+
+	for {
+		now := s.clock.Now()
+		var due []*schedulerEntry
+
+		s.mu.Lock()
+		for len(s.entries) > 0 && !s.entries[0].deadline.After(now) {
+			due = append(due, heap.Pop(&s.entries).(*schedulerEntry))
+		}
+		var next time.Time
+		hasNext := len(s.entries) > 0
+		if hasNext {
+			next = s.entries[0].deadline
+		}
+		s.mu.Unlock()
+
+		for _, entry := range due {
+			s.firing.Add(1)
+			go func(entry *schedulerEntry) {
+				defer s.firing.Done()
+				entry.fire()
+			}(entry)
+		}
+
+		stopTimer(timer)
+		if hasNext {
+			timer.ResetAt(next)
+		}
+
+		select {
+		case <-timer.C():
+		case <-s.wake:
+		case <-s.stopCh:
+			stopTimer(timer)
+			return
+		}
+	}
+}
+
+// stopTimer stops timer and drains its channel
+func stopTimer(timer clock.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C():
+		default:
+		}
+	}
+}
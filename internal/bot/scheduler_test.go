@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-timer/internal/clock"
+)
+
+func TestSchedulerFiresInDeadlineOrder(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := newScheduler(clk)
+	defer s.stop()
+
+	fired := make(chan int, 3)
+	now := clk.Now()
+	for _, n := range []int{3, 1, 2} {
+		n := n
+		s.schedule(now.Add(time.Duration(n)*time.Second), func() { fired <- n })
+	}
+
+	for want := 1; want <= 3; want++ {
+		clk.Advance(time.Second)
+		if got := receiveFired(t, fired); got != want {
+			t.Fatalf("expected entry %d to fire, got %d", want, got)
+		}
+	}
+}
+
+func TestSchedulerCancelAndReschedule(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := newScheduler(clk)
+	defer s.stop()
+
+	fired := make(chan int, 2)
+	cancelled := s.schedule(clk.Now().Add(2*time.Second), func() { fired <- 1 })
+	moved := s.schedule(clk.Now().Add(time.Hour), func() { fired <- 2 })
+
+	if !s.cancel(cancelled) {
+		t.Fatal("expected cancel of scheduled entry to succeed")
+	}
+	if s.cancel(cancelled) {
+		t.Fatal("expected second cancel to fail")
+	}
+	if !s.reschedule(moved, clk.Now().Add(time.Second)) {
+		t.Fatal("expected reschedule to succeed")
+	}
+
+	clk.Advance(time.Second)
+	if got := receiveFired(t, fired); got != 2 {
+		t.Fatalf("expected rescheduled entry to fire, got %d", got)
+	}
+
+	clk.Advance(time.Hour)
+	select {
+	case got := <-fired:
+		t.Fatalf("unexpected fire of entry %d", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if n := s.len(); n != 0 {
+		t.Fatalf("expected empty scheduler, got %d entries", n)
+	}
+}
+
+func receiveFired(t *testing.T, fired <-chan int) int {
+	t.Helper()
+
+	select {
+	case n := <-fired:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("scheduled entry did not fire")
+		return 0
+	}
+}
+
+const benchmarkTimers = 100000
+
+// BenchmarkSchedulerSetCancel100k sets and cancels 100k timers in heap scheduler
+func BenchmarkSchedulerSetCancel100k(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s := newScheduler(clock.Real())
+		entries := make([]*schedulerEntry, benchmarkTimers)
+
+		now := time.Now()
+		for j := range entries {
+			entries[j] = s.schedule(now.Add(time.Hour+time.Duration(j)*time.Millisecond), func() {})
+		}
+		for _, entry := range entries {
+			s.cancel(entry)
+		}
+
+		s.stop()
+	}
+}
+
+// BenchmarkSchedulerReschedule100k reschedules every timer of 100k in heap scheduler
+func BenchmarkSchedulerReschedule100k(b *testing.B) {
+	s := newScheduler(clock.Real())
+	defer s.stop()
+
+	entries := make([]*schedulerEntry, benchmarkTimers)
+	now := time.Now()
+	for j := range entries {
+		entries[j] = s.schedule(now.Add(time.Hour+time.Duration(j)*time.Millisecond), func() {})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry := entries[i%benchmarkTimers]
+		s.reschedule(entry, now.Add(2*time.Hour-time.Duration(i%benchmarkTimers)*time.Millisecond))
+	}
+}
+
+// BenchmarkGoroutinePerTimerSetCancel100k sets and cancels 100k timers the way
+// runTimer used to: goroutine with time.After and cancellable context per timer
+func BenchmarkGoroutinePerTimerSetCancel100k(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		cancels := make([]context.CancelFunc, benchmarkTimers)
+
+		for j := range cancels {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancels[j] = cancel
+			duration := time.Hour + time.Duration(j)*time.Millisecond
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case <-ctx.Done():
+				case <-time.After(duration):
+				}
+			}()
+		}
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		wg.Wait()
+	}
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"sync"
+
+	"tg-timer/internal/i18n"
+)
+
+// ChatSettings stores per-chat preferences with thread safety
+type ChatSettings struct {
+	locales map[int64]i18n.Locale // chatID -> locale override
+	mu      sync.RWMutex
+}
+
+// NewChatSettings creates new chat settings store
+func NewChatSettings() *ChatSettings {
+	return &ChatSettings{
+		locales: make(map[int64]i18n.Locale),
+	}
+}
+
+// Locale returns locale override for chat
+func (cs *ChatSettings) Locale(chatID int64) (i18n.Locale, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	locale, exists := cs.locales[chatID]
+	return locale, exists
+}
+
+// SetLocale sets locale override for chat
+func (cs *ChatSettings) SetLocale(chatID int64, locale i18n.Locale) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.locales[chatID] = locale
+}
+
+// Delete forgets all settings of chat
+func (cs *ChatSettings) Delete(chatID int64) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	delete(cs.locales, chatID)
+}
+
+// Migrate moves settings of group migrated to supergroup to new chat ID
+func (cs *ChatSettings) Migrate(oldChatID, newChatID int64) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if locale, exists := cs.locales[oldChatID]; exists {
+		cs.locales[newChatID] = locale
+		delete(cs.locales, oldChatID)
+	}
+}
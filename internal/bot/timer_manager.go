@@ -2,69 +2,227 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"tg-timer/internal/clock"
+	"tg-timer/internal/config"
 	"tg-timer/pkg/telegram"
 )
 
-// TimerManager manages active timers with thread safety
+var (
+	// ErrChatTimerLimit is returned when chat has maximum number of timers
+	ErrChatTimerLimit = errors.New("too many timers in chat")
+	// ErrGlobalTimerLimit is returned when bot has maximum number of timers
+	ErrGlobalTimerLimit = errors.New("too many active timers")
+)
+
+// TimerManager manages active timers with thread safety. All timers share
+// single scheduler goroutine instead of goroutine per timer.
 type TimerManager struct {
-	timers   map[int64]*Timer // chatID -> Timer
-	mu       sync.RWMutex
-	telegram telegram.Client
+	timers    map[int64][]*Timer // chatID -> Timers, oldest first
+	byID      map[uint64]*Timer  // timer ID -> Timer
+	lastID    uint64             // ID of last created timer
+	count     int                // total number of active timers
+	mu        sync.RWMutex
+	telegram  telegram.Client
+	limits    config.Limits
+	clock     clock.Clock
+	scheduler *scheduler
+	observer  Observer
+	chatGone  []func(chatID int64)               // called when chat becomes unavailable
+	migrated  []func(oldChatID, newChatID int64) // called when group is migrated to supergroup
+
+	// ctx is used for completion messages, cancelled by StopAll
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// NewTimerManager creates new timer manager
-func NewTimerManager(telegram telegram.Client) *TimerManager {
+// NewTimerManager creates new timer manager and starts its scheduler
+func NewTimerManager(telegram telegram.Client, limits config.Limits) *TimerManager {
+	return NewTimerManagerWithClock(telegram, limits, clock.Real())
+}
+
+// NewTimerManagerWithClock creates new timer manager using clk for all time measurements
+func NewTimerManagerWithClock(telegram telegram.Client, limits config.Limits, clk clock.Clock) *TimerManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &TimerManager{
-		timers:   make(map[int64]*Timer),
-		telegram: telegram,
+		timers:    make(map[int64][]*Timer),
+		byID:      make(map[uint64]*Timer),
+		telegram:  telegram,
+		limits:    limits,
+		clock:     clk,
+		scheduler: newScheduler(clk),
+		observer:  nopObserver{},
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
-// SetTimer creates new timer for chat, canceling existing one if present
-func (tm *TimerManager) SetTimer(ctx context.Context, chatID int64, duration time.Duration, durationText string) error {
+// SetObserver sets receiver of timer events, must be called before timers are set
+func (tm *TimerManager) SetObserver(observer Observer) {
+	tm.observer = observer
+}
+
+// SetTimer creates new timer for chat. If only one timer per chat is allowed,
+// existing one is cancelled. Notification is sent to chat when timer completes.
+// Replacing, limit checks and scheduling happen atomically, so concurrent
+// calls for same chat can't leave extra timers.
+func (tm *TimerManager) SetTimer(ctx context.Context, chatID int64, duration time.Duration, notification string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	// Cancel existing timer if any
-	tm.CancelTimer(chatID)
+	if tm.limits.MaxTimersPerChat <= 1 {
+		tm.cancelLocked(chatID)
+	}
 
-	// Create context for this timer
-	timerCtx, cancel := context.WithCancel(ctx)
+	if len(tm.timers[chatID]) >= tm.limits.MaxTimersPerChat {
+		return ErrChatTimerLimit
+	}
+	if tm.limits.MaxTimersGlobal > 0 && tm.count >= tm.limits.MaxTimersGlobal {
+		return ErrGlobalTimerLimit
+	}
 
-	// Create timer object
+	// Store and schedule timer
+	tm.lastID++
 	timer := &Timer{
-		ChatID:     chatID,
-		Duration:   duration,
-		StartTime:  time.Now(),
-		CancelFunc: cancel,
+		ID:           tm.lastID,
+		ChatID:       chatID,
+		Duration:     duration,
+		StartTime:    tm.clock.Now(),
+		Notification: notification,
 	}
+	tm.timers[chatID] = append(tm.timers[chatID], timer)
+	tm.byID[timer.ID] = timer
+	tm.count++
 
-	// Store timer
-	tm.mu.Lock()
-	tm.timers[chatID] = timer
-	tm.mu.Unlock()
-
-	// Start timer in goroutine
-	go tm.runTimer(timerCtx, chatID, duration, durationText)
+	id := timer.ID
+	timer.entry = tm.scheduler.schedule(timer.StartTime.Add(duration), func() {
+		tm.fireTimer(id)
+	})
 
-	log.Printf("Timer set for chat %d: %s", chatID, durationText)
+	log.Printf("Timer %d set for chat %d: %s", timer.ID, chatID, duration)
 	return nil
 }
 
-// CancelTimer cancels active timer for chat
+// CancelTimer cancels all active timers for chat
 func (tm *TimerManager) CancelTimer(chatID int64) bool {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	if timer, exists := tm.timers[chatID]; exists {
-		timer.CancelFunc()
-		delete(tm.timers, chatID)
-		log.Printf("Timer cancelled for chat %d", chatID)
-		return true
+	return tm.cancelLocked(chatID)
+}
+
+// cancelLocked cancels all active timers for chat, tm.mu must be held
+func (tm *TimerManager) cancelLocked(chatID int64) bool {
+	timers, exists := tm.timers[chatID]
+	if !exists {
+		return false
+	}
+
+	for _, timer := range timers {
+		tm.scheduler.cancel(timer.entry)
+		delete(tm.byID, timer.ID)
 	}
+	tm.count -= len(timers)
+	delete(tm.timers, chatID)
+	tm.observer.TimersCancelled(len(timers))
+	log.Printf("Timer cancelled for chat %d", chatID)
+	return true
+}
 
-	return false
+// OnChatGone registers fn to be called when chat becomes unavailable and
+// its timers are purged
+func (tm *TimerManager) OnChatGone(fn func(chatID int64)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.chatGone = append(tm.chatGone, fn)
+}
+
+// PurgeChat forgets chat that can't receive messages anymore (bot was
+// blocked or removed): cancels its timers and calls OnChatGone handlers
+func (tm *TimerManager) PurgeChat(chatID int64) {
+	tm.CancelTimer(chatID)
+
+	tm.mu.RLock()
+	handlers := tm.chatGone
+	tm.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(chatID)
+	}
+	log.Printf("Chat %d purged", chatID)
+}
+
+// OnChatMigrated registers fn to be called when group is migrated to
+// supergroup and its timers are moved to new chat ID
+func (tm *TimerManager) OnChatMigrated(fn func(oldChatID, newChatID int64)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.migrated = append(tm.migrated, fn)
+}
+
+// MigrateChat moves timers of group migrated to supergroup to new chat ID
+// and calls OnChatMigrated handlers. If merged chat exceeds per-chat limit,
+// earliest set timers are kept and the rest are cancelled.
+func (tm *TimerManager) MigrateChat(oldChatID, newChatID int64) {
+	if oldChatID == newChatID {
+		return
+	}
+
+	tm.mu.Lock()
+	timers := tm.timers[oldChatID]
+	for _, timer := range timers {
+		timer.ChatID = newChatID
+	}
+	dropped := 0
+	if len(timers) > 0 {
+		merged := append(tm.timers[newChatID], timers...)
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].StartTime.Before(merged[j].StartTime)
+		})
+		if excess := len(merged) - tm.limits.MaxTimersPerChat; excess > 0 {
+			for _, timer := range merged[len(merged)-excess:] {
+				tm.scheduler.cancel(timer.entry)
+				delete(tm.byID, timer.ID)
+			}
+			merged = merged[:len(merged)-excess]
+			tm.count -= excess
+			dropped = excess
+			tm.observer.TimersCancelled(excess)
+		}
+		tm.timers[newChatID] = merged
+		delete(tm.timers, oldChatID)
+	}
+	handlers := tm.migrated
+	tm.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(oldChatID, newChatID)
+	}
+	log.Printf("Chat %d migrated to %d with %d timers", oldChatID, newChatID, len(timers))
+	if dropped > 0 {
+		log.Printf("Cancelled %d timers over limit in chat %d", dropped, newChatID)
+	}
+}
+
+// ActiveTimers returns number of active timers in all chats
+func (tm *TimerManager) ActiveTimers() int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	return tm.count
 }
 
 // HasActiveTimer checks if chat has active timer
@@ -76,55 +234,179 @@ func (tm *TimerManager) HasActiveTimer(chatID int64) bool {
 	return exists
 }
 
-// StopAll stops all active timers
+// StopAll stops all active timers and scheduler, aborting notifications
+// being sent. Manager must not be used afterwards.
 func (tm *TimerManager) StopAll() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tm.Shutdown(ctx)
+}
+
+// Shutdown stops scheduler and waits until notifications being sent are
+// delivered or ctx is done, then stops all timers. Returns timers that
+// haven't fired, e.g. to restore them after restart. Manager must not be
+// used afterwards.
+func (tm *TimerManager) Shutdown(ctx context.Context) ([]Timer, error) {
+	tm.scheduler.stop()
+
+	sent := make(chan struct{})
+	go func() {
+		tm.scheduler.wait()
+		close(sent)
+	}()
+
+	var err error
+	select {
+	case <-sent:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	tm.cancel()
+	<-sent
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	for chatID, timer := range tm.timers {
-		timer.CancelFunc()
+	remaining := tm.snapshotLocked()
+	for chatID := range tm.timers {
 		log.Printf("Timer stopped for chat %d", chatID)
 	}
 
 	// Clear all timers
-	tm.timers = make(map[int64]*Timer)
+	tm.timers = make(map[int64][]*Timer)
+	tm.byID = make(map[uint64]*Timer)
+	tm.count = 0
+
+	return remaining, err
 }
 
-// runTimer runs timer and sends notification when done
-func (tm *TimerManager) runTimer(ctx context.Context, chatID int64, duration time.Duration, durationText string) {
-	select {
-	case <-ctx.Done():
-		// Timer was cancelled
+// Timers returns copies of active timers, e.g. to save them periodically
+func (tm *TimerManager) Timers() []Timer {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	return tm.snapshotLocked()
+}
+
+// snapshotLocked returns copies of active timers, tm.mu must be held
+func (tm *TimerManager) snapshotLocked() []Timer {
+	snapshot := make([]Timer, 0, tm.count)
+	for _, timers := range tm.timers {
+		for _, timer := range timers {
+			snapshot = append(snapshot, Timer{
+				ID:           timer.ID,
+				ChatID:       timer.ChatID,
+				Duration:     timer.Duration,
+				StartTime:    timer.StartTime,
+				Notification: timer.Notification,
+			})
+		}
+	}
+	return snapshot
+}
+
+// Restore schedules timers saved before restart without checking limits.
+// Timers that should have fired while bot was stopped fire right away.
+func (tm *TimerManager) Restore(timers []Timer) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	for _, saved := range timers {
+		tm.lastID++
+		timer := &Timer{
+			ID:           tm.lastID,
+			ChatID:       saved.ChatID,
+			Duration:     saved.Duration,
+			StartTime:    saved.StartTime,
+			Notification: saved.Notification,
+		}
+		tm.timers[timer.ChatID] = append(tm.timers[timer.ChatID], timer)
+		tm.byID[timer.ID] = timer
+		tm.count++
+
+		id := timer.ID
+		timer.entry = tm.scheduler.schedule(timer.StartTime.Add(timer.Duration), func() {
+			tm.fireTimer(id)
+		})
+	}
+
+	if len(timers) > 0 {
+		log.Printf("Restored %d timers", len(timers))
+	}
+}
+
+// fireTimer is called by scheduler when timer completes; sends notification
+func (tm *TimerManager) fireTimer(id uint64) {
+	timer, ok := tm.remove(id)
+	if !ok {
+		// Timer was cancelled or replaced while firing
 		return
-	case <-time.After(duration):
-		// Timer completed
-		tm.mu.Lock()
-		delete(tm.timers, chatID)
-		tm.mu.Unlock()
-
-		// Send notification
-		err := tm.telegram.SendMessage(ctx, chatID, "Время вышло!")
-		if err != nil {
-			log.Printf("Failed to send timer completion message to chat %d: %v", chatID, err)
+	}
+	tm.observer.TimerFired(tm.clock.Since(timer.StartTime.Add(timer.Duration)))
+
+	err := tm.telegram.SendMessage(tm.ctx, timer.ChatID, timer.Notification)
+	if err != nil {
+		log.Printf("Failed to send timer completion message to chat %d: %v", timer.ChatID, err)
+		if telegram.IsChatUnavailable(err) {
+			tm.PurgeChat(timer.ChatID)
+		}
+	} else {
+		log.Printf("Timer %d completed for chat %d", timer.ID, timer.ChatID)
+	}
+}
+
+// remove removes timer from active ones and returns its copy, taken under
+// lock as chat ID may change on migration; returns false if timer is not active
+func (tm *TimerManager) remove(id uint64) (Timer, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	timer, exists := tm.byID[id]
+	if !exists {
+		return Timer{}, false
+	}
+	delete(tm.byID, id)
+	tm.count--
+
+	timers := tm.timers[timer.ChatID]
+	for i, t := range timers {
+		if t.ID != id {
+			continue
+		}
+
+		timers = append(timers[:i:i], timers[i+1:]...)
+		if len(timers) == 0 {
+			delete(tm.timers, timer.ChatID)
 		} else {
-			log.Printf("Timer completed for chat %d", chatID)
+			tm.timers[timer.ChatID] = timers
 		}
+		break
 	}
+
+	return *timer, true
 }
 
-// GetActiveTimerInfo returns info about active timer for chat
+// GetActiveTimerInfo returns remaining time of the nearest active timer for chat
 func (tm *TimerManager) GetActiveTimerInfo(chatID int64) (time.Duration, bool) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	if timer, exists := tm.timers[chatID]; exists {
-		elapsed := time.Since(timer.StartTime)
+	timers, exists := tm.timers[chatID]
+	if !exists {
+		return 0, false
+	}
+
+	var nearest time.Duration
+	for i, timer := range timers {
+		elapsed := tm.clock.Since(timer.StartTime)
 		remaining := timer.Duration - elapsed
 		if remaining < 0 {
 			remaining = 0
 		}
-		return remaining, true
+		if i == 0 || remaining < nearest {
+			nearest = remaining
+		}
 	}
 
-	return 0, false
+	return nearest, true
 }
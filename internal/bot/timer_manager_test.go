@@ -0,0 +1,343 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-timer/internal/clock"
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+func expectMessage(t *testing.T, client *telegramtest.Client, chatID int64, text string) {
+	t.Helper()
+
+	msg, ok := client.NextMessage(chatID, telegramtest.DefaultTimeout)
+	if !ok {
+		t.Fatalf("expected %q to chat %d, got nothing", text, chatID)
+	}
+	if msg.Text != text {
+		t.Fatalf("expected %q to chat %d, got %q", text, chatID, msg.Text)
+	}
+}
+
+func expectNoMessage(t *testing.T, client *telegramtest.Client, chatID int64) {
+	t.Helper()
+
+	if msg, ok := client.NextMessage(chatID, telegramtest.QuietPeriod); ok {
+		t.Fatalf("unexpected message %q to chat %d", msg.Text, chatID)
+	}
+}
+
+func newTestTimerManager(t *testing.T, limits config.Limits) (*TimerManager, *telegramtest.Client, *clock.Fake) {
+	t.Helper()
+
+	client := telegramtest.NewClient()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	tm := NewTimerManagerWithClock(client, limits, clk)
+	t.Cleanup(tm.StopAll)
+
+	return tm, client, clk
+}
+
+func TestTimerManagerSetAndExpire(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	if err := tm.SetTimer(ctx, 1, 10*time.Second, "done"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if !tm.HasActiveTimer(1) {
+		t.Fatal("expected active timer after SetTimer")
+	}
+
+	clk.Advance(4 * time.Second)
+	remaining, ok := tm.GetActiveTimerInfo(1)
+	if !ok || remaining != 6*time.Second {
+		t.Fatalf("expected 6s remaining, got %s (active: %v)", remaining, ok)
+	}
+	expectNoMessage(t, client, 1)
+
+	clk.Advance(6 * time.Second)
+	expectMessage(t, client, 1, "done")
+
+	if tm.HasActiveTimer(1) {
+		t.Fatal("expected no active timer after expiry")
+	}
+}
+
+func TestTimerManagerCancel(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	if tm.CancelTimer(1) {
+		t.Fatal("expected CancelTimer to report no timer")
+	}
+
+	if err := tm.SetTimer(ctx, 1, 10*time.Second, "done"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if !tm.CancelTimer(1) {
+		t.Fatal("expected CancelTimer to cancel active timer")
+	}
+	if _, ok := tm.GetActiveTimerInfo(1); ok {
+		t.Fatal("expected no timer info after cancel")
+	}
+
+	clk.Advance(time.Minute)
+	expectNoMessage(t, client, 1)
+}
+
+func TestTimerManagerReplace(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	if err := tm.SetTimer(ctx, 1, 5*time.Second, "first"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if err := tm.SetTimer(ctx, 1, 10*time.Second, "second"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+
+	clk.Advance(5 * time.Second)
+	expectNoMessage(t, client, 1)
+
+	clk.Advance(5 * time.Second)
+	expectMessage(t, client, 1, "second")
+	expectNoMessage(t, client, 1)
+}
+
+func TestTimerManagerConcurrentReplace(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tm.SetTimer(ctx, 1, time.Second, "done"); err != nil {
+				t.Errorf("SetTimer failed: %v", err)
+			}
+			tm.CancelTimer(2)
+		}()
+	}
+	wg.Wait()
+
+	tm.mu.RLock()
+	active, count, indexed := len(tm.timers[1]), tm.count, len(tm.byID)
+	tm.mu.RUnlock()
+	if active != 1 || count != 1 || indexed != 1 {
+		t.Fatalf("expected exactly 1 timer, got %d in chat, count %d, %d by ID", active, count, indexed)
+	}
+	if n := tm.scheduler.len(); n != 1 {
+		t.Fatalf("expected 1 scheduled timer, got %d", n)
+	}
+
+	clk.Advance(time.Second)
+	expectMessage(t, client, 1, "done")
+	expectNoMessage(t, client, 1)
+}
+
+func TestTimerManagerStaleFire(t *testing.T) {
+	tm, client, _ := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	if err := tm.SetTimer(ctx, 1, time.Minute, "first"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	tm.mu.RLock()
+	staleID := tm.timers[1][0].ID
+	tm.mu.RUnlock()
+
+	if err := tm.SetTimer(ctx, 1, time.Hour, "second"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+
+	// Firing of replaced timer must not touch the new one
+	tm.fireTimer(staleID)
+	expectNoMessage(t, client, 1)
+	if !tm.HasActiveTimer(1) {
+		t.Fatal("expected new timer to stay active after stale fire")
+	}
+}
+
+func TestTimerManagerChatLimit(t *testing.T) {
+	limits := config.Default().Limits
+	limits.MaxTimersPerChat = 2
+	limits.MaxTimersGlobal = 3
+	tm, client, clk := newTestTimerManager(t, limits)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := tm.SetTimer(ctx, 1, time.Duration(i+1)*time.Second, "chat 1"); err != nil {
+			t.Fatalf("SetTimer failed: %v", err)
+		}
+	}
+	if err := tm.SetTimer(ctx, 1, time.Second, "chat 1"); err != ErrChatTimerLimit {
+		t.Fatalf("expected ErrChatTimerLimit, got %v", err)
+	}
+
+	if err := tm.SetTimer(ctx, 2, time.Second, "chat 2"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if err := tm.SetTimer(ctx, 3, time.Second, "chat 3"); err != ErrGlobalTimerLimit {
+		t.Fatalf("expected ErrGlobalTimerLimit, got %v", err)
+	}
+
+	clk.Advance(time.Second)
+	expectMessage(t, client, 1, "chat 1")
+	expectMessage(t, client, 2, "chat 2")
+	expectNoMessage(t, client, 1)
+}
+
+func TestTimerManagerStopAll(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if err := tm.SetTimer(ctx, chatID, time.Second, "done"); err != nil {
+			t.Fatalf("SetTimer failed: %v", err)
+		}
+	}
+
+	tm.StopAll()
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if tm.HasActiveTimer(chatID) {
+			t.Fatalf("expected no active timer for chat %d after StopAll", chatID)
+		}
+	}
+
+	clk.Advance(time.Minute)
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		expectNoMessage(t, client, chatID)
+	}
+}
+
+func TestTimerManagerShutdownAndRestore(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	if err := tm.SetTimer(ctx, 1, time.Second, "fired"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if err := tm.SetTimer(ctx, 2, time.Minute, "saved"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	clk.Advance(time.Second)
+	expectMessage(t, client, 1, "fired")
+
+	remaining, err := tm.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ChatID != 2 || remaining[0].Notification != "saved" {
+		t.Fatalf("expected only chat 2 timer to remain, got %+v", remaining)
+	}
+
+	// Restarted manager fires overdue timer right away
+	restarted, client, clk := newTestTimerManager(t, config.Default().Limits)
+	clk.Advance(2 * time.Minute)
+	restarted.Restore(remaining)
+	expectMessage(t, client, 2, "saved")
+	if restarted.ActiveTimers() != 0 {
+		t.Fatalf("expected no active timers, got %d", restarted.ActiveTimers())
+	}
+}
+
+func TestTimerManagerPurgesUnavailableChat(t *testing.T) {
+	limits := config.Default().Limits
+	limits.MaxTimersPerChat = 2
+	tm, client, clk := newTestTimerManager(t, limits)
+	ctx := context.Background()
+
+	gone := make(chan int64, 1)
+	tm.OnChatGone(func(chatID int64) { gone <- chatID })
+
+	client.FailChat(1, &telegram.APIError{
+		Method:      "sendMessage",
+		ErrorCode:   403,
+		Description: "Forbidden: bot was blocked by the user",
+	})
+	if err := tm.SetTimer(ctx, 1, time.Second, "first"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	if err := tm.SetTimer(ctx, 1, time.Hour, "second"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+
+	clk.Advance(time.Second)
+	select {
+	case chatID := <-gone:
+		if chatID != 1 {
+			t.Fatalf("expected chat 1 to be purged, got %d", chatID)
+		}
+	case <-time.After(telegramtest.DefaultTimeout):
+		t.Fatal("expected chat to be purged after 403")
+	}
+	if tm.HasActiveTimer(1) {
+		t.Fatal("expected remaining timers of blocked chat to be cancelled")
+	}
+}
+
+func TestTimerManagerMigrateChat(t *testing.T) {
+	tm, client, clk := newTestTimerManager(t, config.Default().Limits)
+	ctx := context.Background()
+
+	migrated := make(chan int64, 1)
+	tm.OnChatMigrated(func(oldChatID, newChatID int64) { migrated <- newChatID })
+
+	if err := tm.SetTimer(ctx, -1, 10*time.Second, "done"); err != nil {
+		t.Fatalf("SetTimer failed: %v", err)
+	}
+	tm.MigrateChat(-1, -1001)
+
+	if newChatID := <-migrated; newChatID != -1001 {
+		t.Fatalf("expected migration to -1001, got %d", newChatID)
+	}
+	if tm.HasActiveTimer(-1) || !tm.HasActiveTimer(-1001) {
+		t.Fatal("expected timer to move to supergroup")
+	}
+
+	clk.Advance(10 * time.Second)
+	expectMessage(t, client, -1001, "done")
+	expectNoMessage(t, client, -1)
+}
+
+func TestTimerManagerMigrateChatLimit(t *testing.T) {
+	limits := config.Default().Limits
+	limits.MaxTimersPerChat = 2
+	tm, client, clk := newTestTimerManager(t, limits)
+	ctx := context.Background()
+
+	// Supergroup already has timer, group brings two more
+	for _, timer := range []struct {
+		chatID   int64
+		duration time.Duration
+		text     string
+	}{
+		{-1001, 30 * time.Second, "supergroup"},
+		{-1, 10 * time.Second, "group 1"},
+		{-1, 5 * time.Second, "group 2"},
+	} {
+		if err := tm.SetTimer(ctx, timer.chatID, timer.duration, timer.text); err != nil {
+			t.Fatalf("SetTimer failed: %v", err)
+		}
+		clk.Advance(time.Second)
+	}
+	tm.MigrateChat(-1, -1001)
+
+	if n := tm.ActiveTimers(); n != 2 {
+		t.Fatalf("expected 2 timers after migration, got %d", n)
+	}
+
+	// Latest set timer is cancelled, although it would fire first
+	clk.Advance(10 * time.Second)
+	expectMessage(t, client, -1001, "group 1")
+	clk.Advance(20 * time.Second)
+	expectMessage(t, client, -1001, "supergroup")
+	expectNoMessage(t, client, -1001)
+}
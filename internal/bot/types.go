@@ -1,16 +1,18 @@
 package bot
 
 import (
-	"context"
 	"time"
 )
 
 // Timer represents an active timer
 type Timer struct {
-	ChatID     int64
-	Duration   time.Duration
-	StartTime  time.Time
-	CancelFunc context.CancelFunc
+	ID           uint64 // unique within TimerManager, never reused
+	ChatID       int64
+	Duration     time.Duration
+	StartTime    time.Time
+	Notification string // Message sent to chat when timer completes
+
+	entry *schedulerEntry
 }
 
 // Command represents parsed command
@@ -19,8 +21,8 @@ type Command struct {
 	Args string
 }
 
-// TimerDuration represents parsed timer duration
-type TimerDuration struct {
+// Reminder represents timer request parsed from natural-language phrase
+type Reminder struct {
 	Duration time.Duration
-	Text     string // Human readable text (e.g., "10 минут")
+	Text     string // Reminder text (e.g., "позвонить маме"), may be empty
 }
@@ -0,0 +1,160 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides current time and timers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer represents single event timer, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	// ResetAt changes timer to fire at deadline. Unlike Reset(d), deadline does
+	// not depend on the moment of the call, so clock can't move in between.
+	ResetAt(deadline time.Time) bool
+}
+
+// Real returns clock backed by time package
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTimer(d time.Duration) Timer  { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+func (t realTimer) ResetAt(deadline time.Time) bool {
+	return t.Timer.Reset(time.Until(deadline))
+}
+
+// Fake is manually advanced clock for tests
+type Fake struct {
+	now    time.Time
+	timers []*fakeTimer
+	mu     sync.Mutex
+}
+
+// NewFake creates fake clock set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns current fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Since returns fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer creates timer firing when clock is advanced by d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1)}
+	f.startLocked(t, f.now.Add(d))
+	return t
+}
+
+// Advance moves fake time forward by d and fires due timers
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	active := f.timers[:0]
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			active = append(active, t)
+			continue
+		}
+
+		t.active = false
+		select {
+		case t.ch <- f.now:
+		default:
+		}
+	}
+	f.timers = active
+}
+
+// startLocked activates timer, firing immediately if deadline is reached
+func (f *Fake) startLocked(t *fakeTimer, deadline time.Time) {
+	t.deadline = deadline
+	if !deadline.After(f.now) {
+		t.active = false
+		select {
+		case t.ch <- f.now:
+		default:
+		}
+		return
+	}
+
+	t.active = true
+	f.timers = append(f.timers, t)
+}
+
+// stopLocked deactivates timer, returns false if it was not active
+func (f *Fake) stopLocked(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+
+	t.active = false
+	for i, timer := range f.timers {
+		if timer == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	active   bool
+	ch       chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	return t.clock.stopLocked(t)
+}
+
+func (t *fakeTimer) ResetAt(deadline time.Time) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.clock.stopLocked(t)
+	t.clock.startLocked(t, deadline)
+	return wasActive
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config represents per-deployment bot configuration
+type Config struct {
+	Limits  Limits
+	Updates Updates
+	Store   Store
+}
+
+// Limits represents timer limits
+type Limits struct {
+	MaxDuration      time.Duration
+	MinDuration      time.Duration
+	MaxTimersPerChat int // 1 means new timer replaces existing one
+	MaxTimersGlobal  int // 0 means unlimited
+}
+
+// Updates represents incoming update processing settings
+type Updates struct {
+	Workers       int           // number of updates processed concurrently
+	ChatQueueSize int           // updates waiting per chat, newer ones are dropped
+	DrainTimeout  time.Duration // time to finish received updates on shutdown
+	DedupWindow   int           // recent update IDs remembered to skip redelivered updates
+}
+
+// Store represents state persistence settings
+type Store struct {
+	File         string        // JSON file timers are saved to, not saved if empty
+	SaveInterval time.Duration // period of saving state while running, so crash loses less
+}
+
+// fileConfig represents JSON config file structure
+type fileConfig struct {
+	Limits struct {
+		MaxDuration      string `json:"max_duration,omitempty"`
+		MinDuration      string `json:"min_duration,omitempty"`
+		MaxTimersPerChat *int   `json:"max_timers_per_chat,omitempty"`
+		MaxTimersGlobal  *int   `json:"max_timers_global,omitempty"`
+	} `json:"limits"`
+	Updates struct {
+		Workers       *int   `json:"workers,omitempty"`
+		ChatQueueSize *int   `json:"chat_queue_size,omitempty"`
+		DrainTimeout  string `json:"drain_timeout,omitempty"`
+		DedupWindow   *int   `json:"dedup_window,omitempty"`
+	} `json:"updates"`
+	Store struct {
+		File         *string `json:"file,omitempty"`
+		SaveInterval string  `json:"save_interval,omitempty"`
+	} `json:"store"`
+}
+
+// Default returns default configuration
+func Default() Config {
+	return Config{
+		Limits: Limits{
+			MaxDuration:      24 * time.Hour,
+			MinDuration:      time.Second,
+			MaxTimersPerChat: 1,
+			MaxTimersGlobal:  0,
+		},
+		Updates: Updates{
+			Workers:       16,
+			ChatQueueSize: 32,
+			DrainTimeout:  10 * time.Second,
+			DedupWindow:   1024,
+		},
+		Store: Store{
+			SaveInterval: time.Minute,
+		},
+	}
+}
+
+// Load loads configuration: defaults, then JSON file from CONFIG_FILE (if set),
+// then environment variables
+func Load() (Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Limits.Validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Updates.Validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Store.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that limits are consistent
+func (l Limits) Validate() error {
+	if l.MinDuration <= 0 {
+		return fmt.Errorf("min duration must be positive, got %s", l.MinDuration)
+	}
+	if l.MaxDuration < l.MinDuration {
+		return fmt.Errorf("max duration %s is less than min duration %s", l.MaxDuration, l.MinDuration)
+	}
+	if l.MaxTimersPerChat < 1 {
+		return fmt.Errorf("max timers per chat must be at least 1, got %d", l.MaxTimersPerChat)
+	}
+	if l.MaxTimersGlobal < 0 {
+		return fmt.Errorf("max timers global must not be negative, got %d", l.MaxTimersGlobal)
+	}
+	return nil
+}
+
+// Validate checks that update processing settings are usable
+func (u Updates) Validate() error {
+	if u.Workers < 1 {
+		return fmt.Errorf("update workers must be at least 1, got %d", u.Workers)
+	}
+	if u.ChatQueueSize < 1 {
+		return fmt.Errorf("chat queue size must be at least 1, got %d", u.ChatQueueSize)
+	}
+	if u.DrainTimeout <= 0 {
+		return fmt.Errorf("drain timeout must be positive, got %s", u.DrainTimeout)
+	}
+	if u.DedupWindow < 1 {
+		return fmt.Errorf("dedup window must be at least 1, got %d", u.DedupWindow)
+	}
+	return nil
+}
+
+// Validate checks that persistence settings are usable
+func (s Store) Validate() error {
+	if s.SaveInterval <= 0 {
+		return fmt.Errorf("save interval must be positive, got %s", s.SaveInterval)
+	}
+	return nil
+}
+
+// loadFile applies values from JSON config file
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if fc.Limits.MaxDuration != "" {
+		if c.Limits.MaxDuration, err = time.ParseDuration(fc.Limits.MaxDuration); err != nil {
+			return fmt.Errorf("invalid limits.max_duration: %w", err)
+		}
+	}
+	if fc.Limits.MinDuration != "" {
+		if c.Limits.MinDuration, err = time.ParseDuration(fc.Limits.MinDuration); err != nil {
+			return fmt.Errorf("invalid limits.min_duration: %w", err)
+		}
+	}
+	if fc.Limits.MaxTimersPerChat != nil {
+		c.Limits.MaxTimersPerChat = *fc.Limits.MaxTimersPerChat
+	}
+	if fc.Limits.MaxTimersGlobal != nil {
+		c.Limits.MaxTimersGlobal = *fc.Limits.MaxTimersGlobal
+	}
+	if fc.Updates.Workers != nil {
+		c.Updates.Workers = *fc.Updates.Workers
+	}
+	if fc.Updates.ChatQueueSize != nil {
+		c.Updates.ChatQueueSize = *fc.Updates.ChatQueueSize
+	}
+	if fc.Updates.DrainTimeout != "" {
+		if c.Updates.DrainTimeout, err = time.ParseDuration(fc.Updates.DrainTimeout); err != nil {
+			return fmt.Errorf("invalid updates.drain_timeout: %w", err)
+		}
+	}
+	if fc.Updates.DedupWindow != nil {
+		c.Updates.DedupWindow = *fc.Updates.DedupWindow
+	}
+	if fc.Store.File != nil {
+		c.Store.File = *fc.Store.File
+	}
+	if fc.Store.SaveInterval != "" {
+		if c.Store.SaveInterval, err = time.ParseDuration(fc.Store.SaveInterval); err != nil {
+			return fmt.Errorf("invalid store.save_interval: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadEnv applies values from environment variables
+func (c *Config) loadEnv() error {
+	if err := envDuration("TIMER_MAX_DURATION", &c.Limits.MaxDuration); err != nil {
+		return err
+	}
+	if err := envDuration("TIMER_MIN_DURATION", &c.Limits.MinDuration); err != nil {
+		return err
+	}
+	if err := envInt("TIMER_MAX_PER_CHAT", &c.Limits.MaxTimersPerChat); err != nil {
+		return err
+	}
+	if err := envInt("TIMER_MAX_GLOBAL", &c.Limits.MaxTimersGlobal); err != nil {
+		return err
+	}
+	if err := envInt("UPDATE_WORKERS", &c.Updates.Workers); err != nil {
+		return err
+	}
+	if err := envInt("UPDATE_CHAT_QUEUE_SIZE", &c.Updates.ChatQueueSize); err != nil {
+		return err
+	}
+	if err := envDuration("UPDATE_DRAIN_TIMEOUT", &c.Updates.DrainTimeout); err != nil {
+		return err
+	}
+	if err := envInt("UPDATE_DEDUP_WINDOW", &c.Updates.DedupWindow); err != nil {
+		return err
+	}
+	if value := os.Getenv("STORE_FILE"); value != "" {
+		c.Store.File = value
+	}
+	if err := envDuration("STORE_SAVE_INTERVAL", &c.Store.SaveInterval); err != nil {
+		return err
+	}
+	return nil
+}
+
+func envDuration(name string, target *time.Duration) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = d
+	return nil
+}
+
+func envInt(name string, target *int) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = n
+	return nil
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var envVars = []string{
+	"CONFIG_FILE", "TIMER_MAX_DURATION", "TIMER_MIN_DURATION", "TIMER_MAX_PER_CHAT", "TIMER_MAX_GLOBAL",
+	"UPDATE_WORKERS", "UPDATE_CHAT_QUEUE_SIZE", "UPDATE_DRAIN_TIMEOUT", "UPDATE_DEDUP_WINDOW", "STORE_FILE", "STORE_SAVE_INTERVAL",
+}
+
+// clearEnv unsets config variables inherited from environment
+func clearEnv(t *testing.T) {
+	for _, name := range envVars {
+		t.Setenv(name, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg != Default() {
+		t.Fatalf("expected defaults %+v, got %+v", Default(), cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, `{
+		"limits": {"max_duration": "168h", "max_timers_per_chat": 3, "max_timers_global": 100},
+		"updates": {"workers": 4, "drain_timeout": "30s"},
+		"store": {"file": "/data/file.json", "save_interval": "30s"}
+	}`))
+	t.Setenv("TIMER_MAX_PER_CHAT", "5")
+	t.Setenv("UPDATE_DRAIN_TIMEOUT", "1m")
+	t.Setenv("STORE_FILE", "/data/env.json")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	want := Default()
+	want.Limits.MaxDuration = 168 * time.Hour  // file
+	want.Limits.MaxTimersPerChat = 5           // env over file
+	want.Limits.MaxTimersGlobal = 100          // file
+	want.Updates.Workers = 4                   // file
+	want.Updates.DrainTimeout = time.Minute    // env over file
+	want.Store.File = "/data/env.json"         // env over file
+	want.Store.SaveInterval = 30 * time.Second // file
+	if cfg != want {
+		t.Fatalf("expected %+v, got %+v", want, cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{"missing file", "", map[string]string{"CONFIG_FILE": "/nonexistent/config.json"}, "failed to read config file"},
+		{"malformed file", "{", nil, "failed to parse config file"},
+		{"file duration", `{"limits": {"max_duration": "week"}}`, nil, "invalid limits.max_duration"},
+		{"file drain timeout", `{"updates": {"drain_timeout": "soon"}}`, nil, "invalid updates.drain_timeout"},
+		{"env duration", "", map[string]string{"TIMER_MIN_DURATION": "1x"}, "invalid TIMER_MIN_DURATION"},
+		{"env int", "", map[string]string{"UPDATE_WORKERS": "many"}, "invalid UPDATE_WORKERS"},
+		{"invalid limits", `{"limits": {"max_timers_per_chat": 0}}`, nil, "max timers per chat"},
+		{"invalid updates", "", map[string]string{"UPDATE_DEDUP_WINDOW": "0"}, "dedup window"},
+		{"file save interval", `{"store": {"save_interval": "often"}}`, nil, "invalid store.save_interval"},
+		{"invalid store", "", map[string]string{"STORE_SAVE_INTERVAL": "0s"}, "save interval must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, tt.file))
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(l *Limits)
+		want   string
+	}{
+		{"zero min duration", func(l *Limits) { l.MinDuration = 0 }, "min duration must be positive"},
+		{"negative min duration", func(l *Limits) { l.MinDuration = -time.Second }, "min duration must be positive"},
+		{"max below min", func(l *Limits) { l.MaxDuration = time.Minute; l.MinDuration = time.Hour }, "is less than min duration"},
+		{"no timers per chat", func(l *Limits) { l.MaxTimersPerChat = 0 }, "max timers per chat must be at least 1"},
+		{"negative global limit", func(l *Limits) { l.MaxTimersGlobal = -1 }, "max timers global must not be negative"},
+	}
+
+	if err := Default().Limits.Validate(); err != nil {
+		t.Fatalf("expected default limits to be valid, got %v", err)
+	}
+
+	for _, tt := range tests {
+		limits := Default().Limits
+		tt.modify(&limits)
+		if err := limits.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestUpdatesValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(u *Updates)
+		want   string
+	}{
+		{"no workers", func(u *Updates) { u.Workers = 0 }, "update workers must be at least 1"},
+		{"no chat queue", func(u *Updates) { u.ChatQueueSize = 0 }, "chat queue size must be at least 1"},
+		{"zero drain timeout", func(u *Updates) { u.DrainTimeout = 0 }, "drain timeout must be positive"},
+		{"no dedup window", func(u *Updates) { u.DedupWindow = 0 }, "dedup window must be at least 1"},
+	}
+
+	if err := Default().Updates.Validate(); err != nil {
+		t.Fatalf("expected default update settings to be valid, got %v", err)
+	}
+
+	for _, tt := range tests {
+		updates := Default().Updates
+		tt.modify(&updates)
+		if err := updates.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestStoreValidate(t *testing.T) {
+	if err := Default().Store.Validate(); err != nil {
+		t.Fatalf("expected default store settings to be valid, got %v", err)
+	}
+	if err := (Store{SaveInterval: -time.Second}).Validate(); err == nil || !strings.Contains(err.Error(), "save interval must be positive") {
+		t.Fatalf("expected error for negative save interval, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locale identifies a message catalog
+type Locale string
+
+const (
+	English Locale = "en"
+	Russian Locale = "ru"
+)
+
+// DefaultLocale is used when user's language is unknown or unsupported
+const DefaultLocale = Russian
+
+// Locales lists all supported locales
+var Locales = []Locale{English, Russian}
+
+// Match returns supported locale for language code (e.g., "en", "ru-RU")
+func Match(languageCode string) (Locale, bool) {
+	code := strings.ToLower(strings.TrimSpace(languageCode))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+
+	for _, locale := range Locales {
+		if string(locale) == code {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
+
+// Resolve returns supported locale for language code, falling back to DefaultLocale
+func Resolve(languageCode string) Locale {
+	if locale, ok := Match(languageCode); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Name returns native name of the locale
+func (l Locale) Name() string {
+	return l.T(MsgLocaleName)
+}
+
+// T returns translated message formatted with args
+func (l Locale) T(key Key, args ...interface{}) string {
+	msg, ok := lookup(l, key)
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) == 0 {
+		return msg.text
+	}
+	return fmt.Sprintf(msg.text, args...)
+}
+
+// N returns translated plural message for number n
+func (l Locale) N(key Key, n int) string {
+	msg, ok := lookup(l, key)
+	if !ok {
+		return fmt.Sprintf("%d %s", n, key)
+	}
+
+	form, ok := msg.forms[PluralCategory(l, n)]
+	if !ok {
+		form = msg.forms[Other]
+	}
+	return fmt.Sprintf(form, n)
+}
+
+// Duration returns human readable duration, rounded down to seconds
+// (e.g., "1 час 30 минут", "7 дней")
+func (l Locale) Duration(d time.Duration) string {
+	if d < time.Second {
+		return l.N(MsgSeconds, 0)
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, l.N(MsgDays, days))
+	}
+	if hours > 0 {
+		parts = append(parts, l.N(MsgHours, hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, l.N(MsgMinutes, minutes))
+	}
+	if seconds > 0 {
+		parts = append(parts, l.N(MsgSeconds, seconds))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// lookup finds message in locale catalog, falling back to DefaultLocale
+func lookup(l Locale, key Key) (message, bool) {
+	if msg, ok := catalogs[l][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[DefaultLocale][key]
+	return msg, ok
+}
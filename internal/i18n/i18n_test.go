@@ -0,0 +1,28 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocaleDuration(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		d      time.Duration
+		want   string
+	}{
+		{Russian, 0, "0 секунд"},
+		{Russian, 90 * time.Minute, "1 час 30 минут"},
+		{Russian, 24 * time.Hour, "1 день"},
+		{Russian, 7 * 24 * time.Hour, "7 дней"},
+		{Russian, 50*time.Hour + time.Second, "2 дня 2 часа 1 секунду"},
+		{English, 168 * time.Hour, "7 days"},
+		{English, 25*time.Hour + 1500*time.Millisecond, "1 day 1 hour 1 second"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.Duration(tt.d); got != tt.want {
+			t.Errorf("%s.Duration(%s) = %q, expected %q", tt.locale, tt.d, got, tt.want)
+		}
+	}
+}
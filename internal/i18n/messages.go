@@ -0,0 +1,165 @@
+package i18n
+
+// Key identifies translatable message
+type Key string
+
+// Message keys
+const (
+	MsgLocaleName       Key = "locale.name"
+	MsgTimerUsage       Key = "timer.usage"
+	MsgInvalidFormat    Key = "timer.invalid_format"
+	MsgInvalidToken     Key = "timer.invalid_token"
+	MsgMaxDuration      Key = "timer.max_duration"
+	MsgMinDuration      Key = "timer.min_duration"
+	MsgSetFailed        Key = "timer.set_failed"
+	MsgChatTimerLimit   Key = "timer.chat_limit"
+	MsgGlobalTimerLimit Key = "timer.global_limit"
+	MsgTimerSet         Key = "timer.set"
+	MsgTimerCancelled   Key = "timer.cancelled"
+	MsgNoActiveTimer    Key = "timer.not_found"
+	MsgTimerStatus      Key = "timer.status"
+	MsgTimeUp           Key = "timer.time_up"
+	MsgReminderSet      Key = "reminder.set"
+	MsgReminder         Key = "reminder.fired"
+	MsgUnknownCommand   Key = "command.unknown"
+	MsgLangUsage        Key = "lang.usage"
+	MsgLangChanged      Key = "lang.changed"
+	MsgLangUnknown      Key = "lang.unknown"
+	MsgSeconds          Key = "unit.seconds"
+	MsgMinutes          Key = "unit.minutes"
+	MsgHours            Key = "unit.hours"
+	MsgDays             Key = "unit.days"
+
+	MsgErrEmpty         Key = "duration.empty"
+	MsgErrSign          Key = "duration.sign"
+	MsgErrNumber        Key = "duration.number"
+	MsgErrMissingNumber Key = "duration.missing_number"
+	MsgErrMissingUnit   Key = "duration.missing_unit"
+	MsgErrUnknownUnit   Key = "duration.unknown_unit"
+	MsgErrUnexpected    Key = "duration.unexpected"
+	MsgErrCalendarUnit  Key = "duration.calendar_unit"
+	MsgErrNotPositive   Key = "duration.not_positive"
+	MsgErrTooLarge      Key = "duration.too_large"
+)
+
+// message is catalog entry: either plain format text or plural forms
+type message struct {
+	text  string
+	forms map[Category]string
+}
+
+func text(s string) message {
+	return message{text: s}
+}
+
+func plural(forms map[Category]string) message {
+	return message{forms: forms}
+}
+
+var catalogs = map[Locale]map[Key]message{
+	English: {
+		MsgLocaleName:       text("English"),
+		MsgTimerUsage:       text("Usage: /timer Xs or /timer Xm\nExample: /timer 30s, /timer 10m, /timer 1h30m or /timer PT1H30M"),
+		MsgInvalidFormat:    text("Invalid time format. Use: /timer 30s or /timer 10m"),
+		MsgInvalidToken:     text("Invalid time format: %s at «%s» in «%s».\nExamples: /timer 30s, /timer 1h30m, /timer PT1H30M"),
+		MsgMaxDuration:      text("Maximum timer duration is %s"),
+		MsgMinDuration:      text("Minimum timer duration is %s"),
+		MsgSetFailed:        text("Failed to set timer. Please try again."),
+		MsgChatTimerLimit:   text("Timer limit for this chat reached (%d). Cancel timers with /cancel."),
+		MsgGlobalTimerLimit: text("Too many active timers right now. Please try again later."),
+		MsgTimerSet:         text("Timer set for %s."),
+		MsgTimerCancelled:   text("Timer cancelled."),
+		MsgNoActiveTimer:    text("No active timer found."),
+		MsgTimerStatus:      text("Timer fires in %s."),
+		MsgTimeUp:           text("Time's up!"),
+		MsgReminderSet:      text("I'll remind you in %s: %s"),
+		MsgReminder:         text("Time's up! Reminder: %s"),
+		MsgUnknownCommand:   text("Unknown command. Available commands:\n/timer Xs or /timer Xm - set timer\n/cancel - cancel timer\n/status - show remaining time\n/lang en|ru - change language"),
+		MsgLangUsage:        text("Current language: %s\nUsage: /lang en or /lang ru"),
+		MsgLangChanged:      text("Language switched to English."),
+		MsgLangUnknown:      text("Unsupported language. Available: en, ru"),
+		MsgSeconds: plural(map[Category]string{
+			One:   "%d second",
+			Other: "%d seconds",
+		}),
+		MsgMinutes: plural(map[Category]string{
+			One:   "%d minute",
+			Other: "%d minutes",
+		}),
+		MsgHours: plural(map[Category]string{
+			One:   "%d hour",
+			Other: "%d hours",
+		}),
+		MsgDays: plural(map[Category]string{
+			One:   "%d day",
+			Other: "%d days",
+		}),
+		MsgErrEmpty:         text("empty duration"),
+		MsgErrSign:          text("sign is not allowed"),
+		MsgErrNumber:        text("invalid number"),
+		MsgErrMissingNumber: text("number expected"),
+		MsgErrMissingUnit:   text("unit expected"),
+		MsgErrUnknownUnit:   text("unknown unit"),
+		MsgErrUnexpected:    text("unexpected designator"),
+		MsgErrCalendarUnit:  text("years and months are not supported"),
+		MsgErrNotPositive:   text("duration must be positive"),
+		MsgErrTooLarge:      text("duration is too large"),
+	},
+	Russian: {
+		MsgLocaleName:       text("Русский"),
+		MsgTimerUsage:       text("Использование: /timer Xs или /timer Xm\nПример: /timer 30s, /timer 10m, /timer 1h30m или /timer PT1H30M"),
+		MsgInvalidFormat:    text("Неверный формат времени. Используйте: /timer 30s или /timer 10m"),
+		MsgInvalidToken:     text("Неверный формат времени: %s в «%s» (ввод: «%s»).\nПримеры: /timer 30s, /timer 1h30m, /timer PT1H30M"),
+		MsgMaxDuration:      text("Максимальное время таймера - %s"),
+		MsgMinDuration:      text("Минимальное время таймера - %s"),
+		MsgSetFailed:        text("Ошибка при установке таймера. Попробуйте еще раз."),
+		MsgChatTimerLimit:   text("Достигнут лимит таймеров в этом чате (%d). Отменить таймеры: /cancel"),
+		MsgGlobalTimerLimit: text("Сейчас слишком много активных таймеров. Попробуйте позже."),
+		MsgTimerSet:         text("Таймер на %s установлен."),
+		MsgTimerCancelled:   text("Таймер отменён."),
+		MsgNoActiveTimer:    text("Активный таймер не найден."),
+		MsgTimerStatus:      text("Таймер сработает через %s."),
+		MsgTimeUp:           text("Время вышло!"),
+		MsgReminderSet:      text("Напомню через %s: %s"),
+		MsgReminder:         text("Время вышло! Напоминание: %s"),
+		MsgUnknownCommand:   text("Неизвестная команда. Доступные команды:\n/timer Xs или /timer Xm - установить таймер\n/cancel - отменить таймер\n/status - показать оставшееся время\n/lang en|ru - сменить язык"),
+		MsgLangUsage:        text("Текущий язык: %s\nИспользование: /lang en или /lang ru"),
+		MsgLangChanged:      text("Язык переключён на русский."),
+		MsgLangUnknown:      text("Неподдерживаемый язык. Доступны: en, ru"),
+		// Accusative forms: "таймер на 1 секунду", "через 2 секунды", "на 5 секунд"
+		MsgSeconds: plural(map[Category]string{
+			One:   "%d секунду",
+			Few:   "%d секунды",
+			Many:  "%d секунд",
+			Other: "%d секунды",
+		}),
+		MsgMinutes: plural(map[Category]string{
+			One:   "%d минуту",
+			Few:   "%d минуты",
+			Many:  "%d минут",
+			Other: "%d минуты",
+		}),
+		MsgHours: plural(map[Category]string{
+			One:   "%d час",
+			Few:   "%d часа",
+			Many:  "%d часов",
+			Other: "%d часа",
+		}),
+		MsgDays: plural(map[Category]string{
+			One:   "%d день",
+			Few:   "%d дня",
+			Many:  "%d дней",
+			Other: "%d дня",
+		}),
+		MsgErrEmpty:         text("пустое значение"),
+		MsgErrSign:          text("знак не допускается"),
+		MsgErrNumber:        text("неверное число"),
+		MsgErrMissingNumber: text("ожидается число"),
+		MsgErrMissingUnit:   text("не указана единица"),
+		MsgErrUnknownUnit:   text("неизвестная единица"),
+		MsgErrUnexpected:    text("неожиданный элемент"),
+		MsgErrCalendarUnit:  text("годы и месяцы не поддерживаются"),
+		MsgErrNotPositive:   text("время должно быть больше нуля"),
+		MsgErrTooLarge:      text("слишком большое значение"),
+	},
+}
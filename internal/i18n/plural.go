@@ -0,0 +1,83 @@
+package i18n
+
+// Category represents CLDR plural category
+type Category int
+
+const (
+	Other Category = iota
+	Zero
+	One
+	Two
+	Few
+	Many
+)
+
+// Operands represents CLDR plural operands of a decimal number
+// (see https://unicode.org/reports/tr35/tr35-numbers.html#Operands)
+type Operands struct {
+	N float64 // absolute value
+	I int64   // integer digits
+	V int     // number of visible fraction digits, with trailing zeros
+	W int     // number of visible fraction digits, without trailing zeros
+	F int64   // visible fraction digits, with trailing zeros
+	T int64   // visible fraction digits, without trailing zeros
+}
+
+// intOperands returns plural operands of integer n
+func intOperands(n int64) Operands {
+	if n < 0 {
+		n = -n
+	}
+	return Operands{N: float64(n), I: n}
+}
+
+// pluralRule maps plural operands to category
+type pluralRule func(ops Operands) Category
+
+// pluralRules contains CLDR cardinal plural rules per locale
+var pluralRules = map[Locale]pluralRule{
+	// one: i = 1 and v = 0
+	English: func(ops Operands) Category {
+		if ops.I == 1 && ops.V == 0 {
+			return One
+		}
+		return Other
+	},
+	// one:  v = 0 and i % 10 = 1 and i % 100 != 11
+	// few:  v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+	// many: v = 0 and (i % 10 = 0 or i % 10 = 5..9 or i % 100 = 11..14)
+	Russian: func(ops Operands) Category {
+		if ops.V != 0 {
+			return Other
+		}
+
+		mod10 := ops.I % 10
+		mod100 := ops.I % 100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return One
+		case inRange(mod10, 2, 4) && !inRange(mod100, 12, 14):
+			return Few
+		default:
+			return Many
+		}
+	},
+}
+
+// PluralCategory returns plural category of integer n for locale
+func PluralCategory(l Locale, n int) Category {
+	return pluralCategory(l, intOperands(int64(n)))
+}
+
+// pluralCategory returns plural category of number operands for locale
+func pluralCategory(l Locale, ops Operands) Category {
+	rule, ok := pluralRules[l]
+	if !ok {
+		rule = pluralRules[DefaultLocale]
+	}
+	return rule(ops)
+}
+
+func inRange(n, from, to int64) bool {
+	return n >= from && n <= to
+}
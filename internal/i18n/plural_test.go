@@ -0,0 +1,84 @@
+package i18n
+
+import "testing"
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		n       int
+		russian Category
+		english Category
+	}{
+		{0, Many, Other},
+		{1, One, One},
+		{2, Few, Other},
+		{5, Many, Other},
+		{11, Many, Other},
+		{12, Many, Other},
+		{14, Many, Other},
+		{21, One, Other},
+		{22, Few, Other},
+		{101, One, Other},
+		{111, Many, Other},
+		{-1, One, One},
+	}
+
+	for _, tt := range tests {
+		if got := PluralCategory(Russian, tt.n); got != tt.russian {
+			t.Errorf("PluralCategory(ru, %d) = %d, expected %d", tt.n, got, tt.russian)
+		}
+		if got := PluralCategory(English, tt.n); got != tt.english {
+			t.Errorf("PluralCategory(en, %d) = %d, expected %d", tt.n, got, tt.english)
+		}
+	}
+}
+
+func TestPluralCategoryFractions(t *testing.T) {
+	tests := []struct {
+		number  string
+		ops     Operands
+		russian Category
+		english Category
+	}{
+		{"1.0", Operands{N: 1, I: 1, V: 1}, Other, Other},
+		{"1.5", Operands{N: 1.5, I: 1, V: 1, W: 1, F: 5, T: 5}, Other, Other},
+		{"0.5", Operands{N: 0.5, V: 1, W: 1, F: 5, T: 5}, Other, Other},
+		{"2.25", Operands{N: 2.25, I: 2, V: 2, W: 2, F: 25, T: 25}, Other, Other},
+		{"21.10", Operands{N: 21.1, I: 21, V: 2, W: 1, F: 10, T: 1}, Other, Other},
+	}
+
+	for _, tt := range tests {
+		if got := pluralCategory(Russian, tt.ops); got != tt.russian {
+			t.Errorf("pluralCategory(ru, %s) = %d, expected %d", tt.number, got, tt.russian)
+		}
+		if got := pluralCategory(English, tt.ops); got != tt.english {
+			t.Errorf("pluralCategory(en, %s) = %d, expected %d", tt.number, got, tt.english)
+		}
+	}
+}
+
+func TestPluralCategoryUnknownLocale(t *testing.T) {
+	if got := PluralCategory(Locale("de"), 22); got != Few {
+		t.Errorf("expected unknown locale to use %s rules, got category %d", DefaultLocale, got)
+	}
+}
+
+func TestLocaleN(t *testing.T) {
+	tests := []struct {
+		locale Locale
+		n      int
+		want   string
+	}{
+		{Russian, 1, "1 минуту"},
+		{Russian, 3, "3 минуты"},
+		{Russian, 11, "11 минут"},
+		{Russian, 21, "21 минуту"},
+		{English, 1, "1 minute"},
+		{English, 0, "0 minutes"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.N(MsgMinutes, tt.n); got != tt.want {
+			t.Errorf("%s.N(%d) = %q, expected %q", tt.locale, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package monitoring collects bot and Telegram API metrics
+package monitoring
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/pkg/metrics"
+	"tg-timer/pkg/telegram"
+)
+
+var (
+	// latencyBuckets are upper bounds of Telegram API latency histogram, seconds
+	latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	// latenessBuckets are upper bounds of timer firing lateness histogram, seconds
+	latenessBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+)
+
+// Metrics implements bot.Observer and telegram.Observer collecting metrics
+// into Registry
+type Metrics struct {
+	Registry *metrics.Registry
+
+	updates         *metrics.CounterVec
+	duplicates      *metrics.Counter
+	commands        *metrics.CounterVec
+	timersFired     *metrics.Counter
+	timersCancelled *metrics.Counter
+	lateness        *metrics.Histogram
+	apiLatency      *metrics.HistogramVec
+	apiErrors       *metrics.CounterVec
+	apiRetries      *metrics.CounterVec
+}
+
+var (
+	_ bot.Observer      = (*Metrics)(nil)
+	_ telegram.Observer = (*Metrics)(nil)
+)
+
+// New creates metrics in new registry
+func New() *Metrics {
+	reg := metrics.NewRegistry()
+
+	return &Metrics{
+		Registry: reg,
+		updates: reg.NewCounterVec("tg_timer_updates_total",
+			"Updates received by type.", "type"),
+		duplicates: reg.NewCounter("tg_timer_duplicate_updates_total",
+			"Updates skipped because they were already handled."),
+		commands: reg.NewCounterVec("tg_timer_commands_total",
+			"Commands received by name.", "command"),
+		timersFired: reg.NewCounter("tg_timer_timers_fired_total",
+			"Timers completed."),
+		timersCancelled: reg.NewCounter("tg_timer_timers_cancelled_total",
+			"Timers cancelled, replaced or purged."),
+		lateness: reg.NewHistogram("tg_timer_timer_lateness_seconds",
+			"Delay between timer deadline and its firing.", latenessBuckets),
+		apiLatency: reg.NewHistogramVec("tg_timer_telegram_request_duration_seconds",
+			"Telegram Bot API request latency by method.", latencyBuckets, "method"),
+		apiErrors: reg.NewCounterVec("tg_timer_telegram_errors_total",
+			"Failed Telegram Bot API requests by method and error code.", "method", "code"),
+		apiRetries: reg.NewCounterVec("tg_timer_telegram_retries_total",
+			"Repeated Telegram Bot API requests by method.", "method"),
+	}
+}
+
+// Attach observes timer manager and command handler and exports number of
+// active timers
+func (m *Metrics) Attach(timerManager *bot.TimerManager, commandHandler *bot.CommandHandler) {
+	timerManager.SetObserver(m)
+	commandHandler.SetObserver(m)
+	m.Registry.NewGaugeFunc("tg_timer_active_timers", "Active timers in all chats.", func() float64 {
+		return float64(timerManager.ActiveTimers())
+	})
+}
+
+// AttachRateLimiter exports statistics of outbound message rate limiter
+func (m *Metrics) AttachRateLimiter(stats func() telegram.RateLimiterStats) {
+	m.Registry.NewGaugeFunc("tg_timer_telegram_queue_depth", "Messages waiting for rate limiter.", func() float64 {
+		return float64(stats().QueueDepth)
+	})
+	m.Registry.NewGaugeFunc("tg_timer_telegram_queued_chats", "Chats with messages waiting for rate limiter.", func() float64 {
+		return float64(stats().Chats)
+	})
+	m.Registry.NewCounterFunc("tg_timer_telegram_sent_messages_total", "Messages let through by rate limiter.", func() float64 {
+		return float64(stats().Sent)
+	})
+	m.Registry.NewCounterFunc("tg_timer_telegram_throttled_messages_total", "Messages that had to wait for rate limiter.", func() float64 {
+		return float64(stats().Throttled)
+	})
+	m.Registry.NewCounterFunc("tg_timer_telegram_rejected_messages_total", "Messages rejected because rate limiter queue was full.", func() float64 {
+		return float64(stats().Rejected)
+	})
+	m.Registry.NewCounterFunc("tg_timer_telegram_throttle_wait_seconds_total", "Time spent waiting by throttled messages.", func() float64 {
+		return stats().TotalWait.Seconds()
+	})
+	m.Registry.NewGaugeFunc("tg_timer_telegram_throttle_max_wait_seconds", "Longest wait of single throttled message.", func() float64 {
+		return stats().MaxWait.Seconds()
+	})
+}
+
+// UpdateReceived implements bot.Observer
+func (m *Metrics) UpdateReceived(kind string) {
+	m.updates.With(kind).Inc()
+}
+
+// UpdateDuplicated implements bot.Observer
+func (m *Metrics) UpdateDuplicated() {
+	m.duplicates.Inc()
+}
+
+// CommandReceived implements bot.Observer
+func (m *Metrics) CommandReceived(name string) {
+	m.commands.With(name).Inc()
+}
+
+// TimerFired implements bot.Observer
+func (m *Metrics) TimerFired(lateness time.Duration) {
+	m.timersFired.Inc()
+	m.lateness.Observe(lateness.Seconds())
+}
+
+// TimersCancelled implements bot.Observer
+func (m *Metrics) TimersCancelled(n int) {
+	m.timersCancelled.Add(float64(n))
+}
+
+// ObserveRequest implements telegram.Observer
+func (m *Metrics) ObserveRequest(method string, duration time.Duration, err error) {
+	m.apiLatency.With(method).Observe(duration.Seconds())
+	if err != nil {
+		m.apiErrors.With(method, errorCode(err)).Inc()
+	}
+}
+
+// ObserveRetry implements telegram.Observer
+func (m *Metrics) ObserveRetry(method string) {
+	m.apiRetries.With(method).Inc()
+}
+
+// errorCode returns Bot API error code or "transport" for network and
+// decoding errors
+func errorCode(err error) string {
+	var apiErr *telegram.APIError
+	if errors.As(err, &apiErr) {
+		return strconv.Itoa(apiErr.ErrorCode)
+	}
+	return "transport"
+}
@@ -0,0 +1,145 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-timer/internal/bot"
+	"tg-timer/internal/clock"
+	"tg-timer/internal/config"
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	var b strings.Builder
+	if _, err := m.Registry.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	return b.String()
+}
+
+func expectLines(t *testing.T, output string, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected %q in metrics:\n%s", line, output)
+		}
+	}
+}
+
+func TestTelegramMetrics(t *testing.T) {
+	m := New()
+	server := telegramtest.NewServer("123:test")
+	defer server.Close()
+
+	client := telegram.NewClientWithOptions("123:test", telegram.Options{
+		BaseURL:      server.URL,
+		RetryBackoff: time.Millisecond,
+		RateLimits:   &telegram.RateLimits{},
+		Observer:     m,
+	})
+
+	server.Fail("sendMessage", telegramtest.Fault{Status: http.StatusBadGateway})
+	if err := client.SendMessage(context.Background(), 1, "hello"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	expectLines(t, scrape(t, m),
+		`tg_timer_telegram_errors_total{method="sendMessage",code="502"} 1`,
+		`tg_timer_telegram_retries_total{method="sendMessage"} 1`,
+		`tg_timer_telegram_request_duration_seconds_count{method="sendMessage"} 2`,
+	)
+}
+
+func TestBotMetrics(t *testing.T) {
+	m := New()
+	client := telegramtest.NewClient()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	limits := config.Default().Limits
+	timerManager := bot.NewTimerManagerWithClock(client, limits, clk)
+	defer timerManager.StopAll()
+	commandHandler := bot.NewCommandHandler(timerManager, client, limits)
+	m.Attach(timerManager, commandHandler)
+
+	ctx := context.Background()
+	chat := telegram.Chat{ID: 1, Type: "private"}
+	for _, text := range []string{"/timer 5s", "/timer 10s", "/foo"} {
+		commandHandler.HandleUpdate(ctx, telegram.Update{Message: &telegram.Message{Text: text, Chat: chat}})
+	}
+	expectLines(t, scrape(t, m),
+		`tg_timer_updates_total{type="message"} 3`,
+		`tg_timer_commands_total{command="timer"} 2`,
+		`tg_timer_commands_total{command="unknown"} 1`,
+		`tg_timer_timers_cancelled_total 1`,
+		`tg_timer_active_timers 1`,
+	)
+
+	clk.Advance(10 * time.Second)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(scrape(t, m), "tg_timer_timers_fired_total 1\n") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	expectLines(t, scrape(t, m),
+		`tg_timer_timers_fired_total 1`,
+		`tg_timer_timer_lateness_seconds_count 1`,
+		`tg_timer_active_timers 0`,
+	)
+}
+
+func TestRateLimiterMetrics(t *testing.T) {
+	m := New()
+	server := telegramtest.NewServer("123:test")
+	defer server.Close()
+
+	client := telegram.NewClientWithOptions("123:test", telegram.Options{
+		BaseURL: server.URL,
+		RateLimits: &telegram.RateLimits{
+			PerChat:   telegram.Rate{Count: 1, Per: 50 * time.Millisecond},
+			QueueSize: 1,
+		},
+	})
+	m.AttachRateLimiter(client.Stats)
+	ctx := context.Background()
+
+	if err := client.SendMessage(ctx, 1, "first"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	waited := make(chan error, 1)
+	go func() { waited <- client.SendMessage(ctx, 1, "second") }()
+
+	deadline := time.Now().Add(time.Second)
+	for client.Stats().QueueDepth != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	expectLines(t, scrape(t, m),
+		`tg_timer_telegram_queue_depth 1`,
+		`tg_timer_telegram_queued_chats 1`,
+		`tg_timer_telegram_throttled_messages_total 1`,
+	)
+
+	if err := client.SendMessage(ctx, 2, "third"); err != telegram.ErrRateLimitQueueFull {
+		t.Fatalf("expected ErrRateLimitQueueFull, got %v", err)
+	}
+	if err := <-waited; err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	output := scrape(t, m)
+	expectLines(t, output,
+		`tg_timer_telegram_queue_depth 0`,
+		`tg_timer_telegram_sent_messages_total 2`,
+		`tg_timer_telegram_rejected_messages_total 1`,
+	)
+	for _, name := range []string{"tg_timer_telegram_throttle_wait_seconds_total", "tg_timer_telegram_throttle_max_wait_seconds"} {
+		if strings.Contains(output, name+" 0\n") || !strings.Contains(output, "\n"+name+" ") {
+			t.Errorf("expected non-zero %s in metrics:\n%s", name, output)
+		}
+	}
+}
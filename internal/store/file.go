@@ -0,0 +1,72 @@
+// Package store persists bot state between restarts
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// File keeps JSON snapshot of state in file
+type File struct {
+	path string
+}
+
+// NewFile creates store writing to path
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Path returns file path
+func (f *File) Path() string {
+	return f.path
+}
+
+// Load decodes snapshot into v, returns false if nothing was saved yet
+func (f *File) Load(v interface{}) (bool, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse store %s: %w", f.path, err)
+	}
+	return true, nil
+}
+
+// Save replaces snapshot with v. File is written to temporary file first and
+// renamed, so crash during save doesn't corrupt previous snapshot.
+func (f *File) Save(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	f := NewFile(filepath.Join(dir, "state.json"))
+
+	var state map[string]int
+	if found, err := f.Load(&state); err != nil || found {
+		t.Fatalf("expected empty store, got found %v, err %v", found, err)
+	}
+
+	if err := f.Save(map[string]int{"a": 1}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := f.Save(map[string]int{"b": 2}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if found, err := f.Load(&state); err != nil || !found {
+		t.Fatalf("expected saved state, got found %v, err %v", found, err)
+	}
+	if len(state) != 1 || state["b"] != 2 {
+		t.Fatalf("expected last saved state, got %v", state)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be removed, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,357 @@
+// Package metrics implements counters, gauges and histograms exposed in
+// Prometheus text format without third-party dependencies
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric is single metric family
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics in registration order
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// NewRegistry creates empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds metric, panics on duplicate name as it is programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes all metrics in Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler returns HTTP handler serving metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// desc describes metric family
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// labelPairs formats label set, extra is appended as is (e.g. le="0.1")
+func (d *desc) labelPairs(values []string, extra string) string {
+	if len(d.labels) == 0 && extra == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, label := range d.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	if extra != "" {
+		if len(d.labels) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// series holds children of vector metric keyed by label values
+type series[T any] struct {
+	mu       sync.Mutex
+	children map[string]*child[T]
+}
+
+type child[T any] struct {
+	values []string
+	value  *T
+}
+
+// get returns child for label values, creating it with create if needed
+func (s *series[T]) get(d *desc, values []string, create func() *T) *T {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.children == nil {
+		s.children = make(map[string]*child[T])
+	}
+	c, ok := s.children[key]
+	if !ok {
+		c = &child[T]{values: append([]string(nil), values...), value: create()}
+		s.children[key] = c
+	}
+	return c.value
+}
+
+// sorted returns children ordered by label values
+func (s *series[T]) sorted() []*child[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	children := make([]*child[T], 0, len(s.children))
+	for _, c := range s.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return strings.Join(children[i].values, "\xff") < strings.Join(children[j].values, "\xff")
+	})
+	return children
+}
+
+// Counter is monotonically increasing value
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc increments counter by 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases counter by v, negative values are ignored
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+// Value returns current value
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.value
+}
+
+// CounterVec is counter partitioned by labels
+type CounterVec struct {
+	desc
+	series series[Counter]
+}
+
+// NewCounterVec registers counter with labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}}
+	r.register(name, v)
+	return v
+}
+
+// NewCounter registers counter without labels
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// With returns counter for label values
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.series.get(&v.desc, values, func() *Counter { return &Counter{} })
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	for _, c := range v.series.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.labelPairs(c.values, ""), formatFloat(c.value.Value()))
+	}
+}
+
+// CounterFunc is counter which value is read on every scrape, e.g. from
+// statistics kept elsewhere; fn must never decrease
+type CounterFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewCounterFunc registers counter read from fn
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) *CounterFunc {
+	c := &CounterFunc{desc: desc{name: name, help: help, kind: "counter"}, fn: fn}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterFunc) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", c.name, formatFloat(c.fn()))
+}
+
+// GaugeFunc is gauge which value is computed on every scrape
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers gauge computed by fn
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // upper bounds, sorted
+	counts  []uint64  // per bucket, not cumulative
+	count   uint64
+	sum     float64
+}
+
+// Observe adds observation
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// HistogramVec is histogram partitioned by labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	series  series[Histogram]
+}
+
+// NewHistogramVec registers histogram with bucket upper bounds and labels
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	v := &HistogramVec{desc: desc{name: name, help: help, kind: "histogram", labels: labels}, buckets: buckets}
+	r.register(name, v)
+	return v
+}
+
+// NewHistogram registers histogram without labels
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+// With returns histogram for label values
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.series.get(&v.desc, values, func() *Histogram {
+		return &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+	})
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	for _, c := range v.series.sorted() {
+		h := c.value
+		h.mu.Lock()
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += h.counts[i]
+			le := `le="` + formatFloat(bound) + `"`
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, v.labelPairs(c.values, le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, v.labelPairs(c.values, `le="+Inf"`), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, v.labelPairs(c.values, ""), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, v.labelPairs(c.values, ""), h.count)
+		h.mu.Unlock()
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// countingWriter counts bytes written
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
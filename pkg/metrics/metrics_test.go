@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Requests by method.", "method", "code")
+	requests.With("sendMessage", "200").Add(2)
+	requests.With("getUpdates", "200").Inc()
+	requests.With("sendMessage", `4"03`).Inc()
+
+	reg.NewGaugeFunc("active_timers", "Active timers.", func() float64 { return 7 })
+	reg.NewCounterFunc("sent_total", "Messages sent.", func() float64 { return 12 })
+
+	latency := reg.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1})
+	latency.Observe(0.05)
+	latency.Observe(0.1)
+	latency.Observe(0.5)
+	latency.Observe(3)
+
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	want := `# HELP requests_total Requests by method.
+# TYPE requests_total counter
+requests_total{method="getUpdates",code="200"} 1
+requests_total{method="sendMessage",code="200"} 2
+requests_total{method="sendMessage",code="4\"03"} 1
+# HELP active_timers Active timers.
+# TYPE active_timers gauge
+active_timers 7
+# HELP sent_total Messages sent.
+# TYPE sent_total counter
+sent_total 12
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 2
+latency_seconds_bucket{le="1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 3.65
+latency_seconds_count 4
+`
+	if got := b.String(); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("events_total", "Events.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "events_total 1\n") {
+		t.Fatalf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("events_total", "Events.")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate metric")
+		}
+	}()
+	reg.NewCounter("events_total", "Events.")
+}
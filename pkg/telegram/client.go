@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,25 +20,98 @@ import (
 type Client interface {
 	GetUpdates(ctx context.Context, offset int, timeout int) ([]Update, error)
 	SendMessage(ctx context.Context, chatID int64, text string) error
-	SetWebhook(ctx context.Context, webhookURL string) error
+	SetWebhook(ctx context.Context, webhookURL string, opts WebhookOptions) error
 	DeleteWebhook(ctx context.Context) error
+	GetWebhookInfo(ctx context.Context) (*WebhookInfo, error)
 }
 
+// Observer receives Bot API call events, e.g. to collect metrics
+type Observer interface {
+	// ObserveRequest is called after every API request; err is nil on success
+	ObserveRequest(method string, duration time.Duration, err error)
+	// ObserveRetry is called before request is repeated after failure
+	ObserveRetry(method string)
+}
+
+// nopObserver ignores all events
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(string, time.Duration, error) {}
+func (nopObserver) ObserveRetry(string)                         {}
+
+// DefaultBaseURL is Telegram Bot API server URL
+const DefaultBaseURL = "https://api.telegram.org"
+
 // HTTPClient represents Telegram Bot API client
 type HTTPClient struct {
-	token   string
-	baseURL string
-	client  *http.Client
+	token        string
+	baseURL      string
+	client       *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	limiter      *rateLimiter
+	observer     Observer
+	lastSuccess  atomic.Int64 // unix nanoseconds of last successful call
+
+	mu             sync.RWMutex
+	migrations     map[int64]int64 // old chat ID -> supergroup chat ID
+	migrationOrder []int64         // old chat IDs, oldest first
+	migrateHooks   []func(oldChatID, newChatID int64)
+}
+
+// maxMigrations is number of remembered group migrations. Migrated chats
+// are moved by OnChatMigrated handlers, so only messages already on the way
+// need old entries.
+const maxMigrations = 1024
+
+// Options configures HTTPClient; zero values mean defaults
+type Options struct {
+	BaseURL      string        // Bot API server URL, DefaultBaseURL by default
+	HTTPClient   *http.Client  // HTTP client, 30s timeout by default
+	MaxRetries   int           // attempts to send message, 3 by default
+	RetryBackoff time.Duration // first retry delay, doubled on each attempt, 2s by default
+	RateLimits   *RateLimits   // outbound message limits, DefaultRateLimits if nil
+	Observer     Observer      // receives API call events, may be nil
 }
 
 // NewClient creates new Telegram client
 func NewClient(token string) Client {
-	return &HTTPClient{
-		token:   token,
-		baseURL: fmt.Sprintf("https://api.telegram.org/bot%s/", token),
-		client: &http.Client{
+	return NewClientWithOptions(token, Options{})
+}
+
+// NewClientWithOptions creates new Telegram client with options
+func NewClientWithOptions(token string, opts Options) *HTTPClient {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
 			Timeout: 30 * time.Second,
-		},
+		}
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 2 * time.Second
+	}
+	if opts.Observer == nil {
+		opts.Observer = nopObserver{}
+	}
+	limits := DefaultRateLimits()
+	if opts.RateLimits != nil {
+		limits = *opts.RateLimits
+	}
+
+	return &HTTPClient{
+		token:        token,
+		baseURL:      fmt.Sprintf("%s/bot%s/", strings.TrimRight(opts.BaseURL, "/"), token),
+		client:       opts.HTTPClient,
+		maxRetries:   opts.MaxRetries,
+		retryBackoff: opts.RetryBackoff,
+		limiter:      newRateLimiter(limits),
+		observer:     opts.Observer,
+		migrations:   make(map[int64]int64),
 	}
 }
 
@@ -52,63 +128,85 @@ func (tc *HTTPClient) GetUpdates(ctx context.Context, offset int, timeout int) (
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := tc.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	var updates []Update
+	if err := tc.do("getUpdates", req, &updates); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
+	return updates, nil
+}
 
-	var result struct {
-		OK          bool     `json:"ok"`
-		Result      []Update `json:"result"`
-		Description string   `json:"description,omitempty"`
-	}
+// SendMessage sends message to chat
+func (tc *HTTPClient) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return tc.sendMessageWithRetry(ctx, chatID, text, tc.maxRetries)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+// OnChatMigrated registers fn to be called when group turns out to be
+// migrated to supergroup with new chat ID
+func (tc *HTTPClient) OnChatMigrated(fn func(oldChatID, newChatID int64)) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 
-	if !result.OK {
-		return nil, fmt.Errorf("API error: %s", result.Description)
-	}
+	tc.migrateHooks = append(tc.migrateHooks, fn)
+}
 
-	return result.Result, nil
+// LastSuccess returns time of last successful Bot API call, zero if none
+func (tc *HTTPClient) LastSuccess() time.Time {
+	nanos := tc.lastSuccess.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
 }
 
-// SendMessage sends message to chat
-func (tc *HTTPClient) SendMessage(ctx context.Context, chatID int64, text string) error {
-	return tc.sendMessageWithRetry(ctx, chatID, text, 3)
+// Stats returns outbound rate limiter statistics
+func (tc *HTTPClient) Stats() RateLimiterStats {
+	return tc.limiter.statistics()
 }
 
-// sendMessageWithRetry sends message with exponential backoff retry
+// sendMessageWithRetry sends message retrying transient errors. Delay
+// requested by Telegram in retry_after is honored exactly, otherwise
+// exponential backoff is used. Message to group migrated to supergroup is
+// resent to new chat ID.
 func (tc *HTTPClient) sendMessageWithRetry(ctx context.Context, chatID int64, text string, maxRetries int) error {
 	req := SendMessageRequest{
-		ChatID: chatID,
+		ChatID: tc.migratedChatID(chatID),
 		Text:   text,
 	}
 
 	var lastErr error
+	migrated := false
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff
-			backoff := time.Duration(math.Pow(2, float64(attempt))) * time.Second
+		if lastErr != nil {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(backoff):
+			case <-time.After(tc.retryDelay(lastErr, attempt)):
 			}
+			tc.observer.ObserveRetry("sendMessage")
+		}
+
+		if err := tc.limiter.wait(ctx, req.ChatID); err != nil {
+			return err
 		}
 
 		err := tc.sendSingleMessage(ctx, req)
 		if err == nil {
 			return nil
 		}
+		if newChatID := MigrateToChatID(err); newChatID != 0 && !migrated {
+			migrated = true
+			tc.migrate(req.ChatID, newChatID)
+			req.ChatID = newChatID
+			// Resending to new chat ID doesn't count as retry
+			attempt--
+			lastErr = nil
+			continue
+		}
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
 
 		lastErr = err
 		log.Printf("Failed to send message (attempt %d/%d): %v", attempt+1, maxRetries, err)
@@ -117,6 +215,48 @@ func (tc *HTTPClient) sendMessageWithRetry(ctx context.Context, chatID int64, te
 	return fmt.Errorf("failed to send message after %d attempts: %w", maxRetries, lastErr)
 }
 
+// migratedChatID returns supergroup chat ID if group was migrated
+func (tc *HTTPClient) migratedChatID(chatID int64) int64 {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	if newChatID, ok := tc.migrations[chatID]; ok {
+		return newChatID
+	}
+	return chatID
+}
+
+// migrate remembers group migration and calls OnChatMigrated handlers
+func (tc *HTTPClient) migrate(oldChatID, newChatID int64) {
+	tc.mu.Lock()
+	if _, ok := tc.migrations[oldChatID]; !ok {
+		tc.migrationOrder = append(tc.migrationOrder, oldChatID)
+		if len(tc.migrationOrder) > maxMigrations {
+			delete(tc.migrations, tc.migrationOrder[0])
+			tc.migrationOrder = tc.migrationOrder[1:]
+		}
+	}
+	tc.migrations[oldChatID] = newChatID
+	hooks := tc.migrateHooks
+	tc.mu.Unlock()
+
+	log.Printf("Chat %d was migrated to supergroup %d", oldChatID, newChatID)
+	for _, fn := range hooks {
+		fn(oldChatID, newChatID)
+	}
+}
+
+// retryDelay returns delay before attempt after err
+func (tc *HTTPClient) retryDelay(err error, attempt int) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter() > 0 {
+		return apiErr.RetryAfter()
+	}
+
+	// Exponential backoff
+	return tc.retryBackoff * time.Duration(math.Pow(2, float64(attempt-1)))
+}
+
 // sendSingleMessage sends single message without retry
 func (tc *HTTPClient) sendSingleMessage(ctx context.Context, req SendMessageRequest) error {
 	jsonData, err := json.Marshal(req)
@@ -132,25 +272,26 @@ func (tc *HTTPClient) sendSingleMessage(ctx context.Context, req SendMessageRequ
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := tc.client.Do(httpReq)
+	return tc.do("sendMessage", httpReq, nil)
+}
+
+// do sends request and decodes response of method into result
+func (tc *HTTPClient) do(method string, req *http.Request, result interface{}) (err error) {
+	start := time.Now()
+	defer func() {
+		tc.observer.ObserveRequest(method, time.Since(start), err)
+	}()
+
+	resp, err := tc.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if !result.OK {
-		return fmt.Errorf("API error: %s", result.Description)
+	if err := decodeResponse(method, resp, result); err != nil {
+		return err
 	}
 
+	tc.lastSuccess.Store(time.Now().UnixNano())
 	return nil
 }
@@ -0,0 +1,337 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+const testToken = "123:test"
+
+func newTestClient(t *testing.T) (*telegram.HTTPClient, *telegramtest.Server) {
+	t.Helper()
+
+	server := telegramtest.NewServer(testToken)
+	t.Cleanup(server.Close)
+
+	client := telegram.NewClientWithOptions(testToken, telegram.Options{
+		BaseURL:      server.URL,
+		HTTPClient:   &http.Client{Timeout: 2 * time.Second},
+		RetryBackoff: time.Millisecond,
+		RateLimits:   &telegram.RateLimits{},
+	})
+	return client, server
+}
+
+func TestGetUpdates(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	server.Inject(telegram.Update{Message: &telegram.Message{
+		Text: "/timer 5s",
+		Chat: telegram.Chat{ID: 42, Type: "private"},
+		From: &telegram.User{ID: 7, LanguageCode: "en"},
+	}})
+	server.Inject(telegram.Update{Message: &telegram.Message{Text: "/cancel", Chat: telegram.Chat{ID: 42}}})
+
+	updates, err := client.GetUpdates(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("GetUpdates failed: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(updates))
+	}
+	first := updates[0]
+	if first.UpdateID != 1 || first.Message.Text != "/timer 5s" || first.Message.Chat.ID != 42 ||
+		first.Message.Chat.Type != "private" || first.Message.From.LanguageCode != "en" {
+		t.Fatalf("unexpected first update: %+v", first.Message)
+	}
+
+	// Offset confirms previous updates
+	updates, err = client.GetUpdates(ctx, 3, 0)
+	if err != nil {
+		t.Fatalf("GetUpdates failed: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected no updates after offset, got %d", len(updates))
+	}
+}
+
+func TestGetUpdatesLongPolling(t *testing.T) {
+	client, server := newTestClient(t)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.Inject(telegram.Update{Message: &telegram.Message{Text: "late", Chat: telegram.Chat{ID: 1}}})
+	}()
+
+	updates, err := client.GetUpdates(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatalf("GetUpdates failed: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Message.Text != "late" {
+		t.Fatalf("expected late update, got %+v", updates)
+	}
+}
+
+func TestGetUpdatesErrors(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	server.Fail("getUpdates", telegramtest.Fault{Body: `{"ok":true,"result":[`})
+	if _, err := client.GetUpdates(ctx, 0, 0); err == nil || !strings.Contains(err.Error(), "decode") {
+		t.Fatalf("expected decode error for malformed JSON, got %v", err)
+	}
+
+	server.Fail("getUpdates", telegramtest.Fault{Status: http.StatusConflict, Description: "Conflict: terminated by other getUpdates request"})
+	if _, err := client.GetUpdates(ctx, 0, 0); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Fatalf("expected 409 error, got %v", err)
+	}
+
+	server.Fail("getUpdates", telegramtest.Fault{Delay: time.Second})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetUpdates(timeoutCtx, 0, 0); err == nil {
+		t.Fatal("expected error for slow response")
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	client, server := newTestClient(t)
+
+	if err := client.SendMessage(context.Background(), 42, "Время вышло!"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	sent := server.SentMessages()
+	if len(sent) != 1 || sent[0].ChatID != 42 || sent[0].Text != "Время вышло!" {
+		t.Fatalf("unexpected sent messages: %+v", sent)
+	}
+}
+
+func TestSendMessageRetries(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.Fail("sendMessage",
+		telegramtest.Fault{Status: http.StatusInternalServerError},
+		telegramtest.Fault{Body: "not json"},
+	)
+
+	if err := client.SendMessage(context.Background(), 42, "hello"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	if n := server.Requests("sendMessage"); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+	if sent := server.SentMessages(); len(sent) != 1 {
+		t.Fatalf("expected 1 delivered message, got %d", len(sent))
+	}
+}
+
+func TestSendMessageGivesUp(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.Fail("sendMessage",
+		telegramtest.Fault{Status: http.StatusBadGateway},
+		telegramtest.Fault{Status: http.StatusBadGateway},
+		telegramtest.Fault{Status: http.StatusBadGateway},
+	)
+
+	err := client.SendMessage(context.Background(), 42, "hello")
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected error after 3 attempts, got %v", err)
+	}
+}
+
+func TestSendMessagePermanentError(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.Fail("sendMessage", telegramtest.Fault{
+		Status:      http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	})
+
+	err := client.SendMessage(context.Background(), 42, "hello")
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.ErrorCode != http.StatusForbidden || apiErr.Retryable() || telegram.IsRetryable(err) || !telegram.IsChatUnavailable(err) {
+		t.Fatalf("expected permanent 403 error, got %+v", apiErr)
+	}
+	if n := server.Requests("sendMessage"); n != 1 {
+		t.Fatalf("expected permanent error not to be retried, got %d attempts", n)
+	}
+}
+
+func TestSendMessageRetryAfter(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.Fail("sendMessage", telegramtest.Fault{
+		Status:      http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after 1",
+		RetryAfter:  1,
+	})
+
+	start := time.Now()
+	if err := client.SendMessage(context.Background(), 42, "hello"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected retry after 1s, retried after %s", elapsed)
+	}
+	if n := server.Requests("sendMessage"); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestSendMessageMigratedChat(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	type migration struct{ from, to int64 }
+	migrated := make(chan migration, 1)
+	client.OnChatMigrated(func(oldChatID, newChatID int64) {
+		migrated <- migration{oldChatID, newChatID}
+	})
+
+	server.Fail("sendMessage", telegramtest.Fault{
+		Status:      http.StatusBadRequest,
+		Description: "Bad Request: group chat was upgraded to a supergroup chat",
+		MigrateTo:   -1001,
+	})
+
+	if err := client.SendMessage(ctx, -1, "first"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	if m := <-migrated; m.from != -1 || m.to != -1001 {
+		t.Fatalf("unexpected migration %+v", m)
+	}
+
+	// Known migration is applied without failed request
+	if err := client.SendMessage(ctx, -1, "second"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	if n := server.Requests("sendMessage"); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+	for _, msg := range server.SentMessages() {
+		if msg.ChatID != -1001 {
+			t.Fatalf("expected message to supergroup, got chat %d", msg.ChatID)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	opts := telegram.WebhookOptions{
+		SecretToken:        "s3cret-token_1",
+		AllowedUpdates:     []string{"message", "my_chat_member"},
+		MaxConnections:     10,
+		DropPendingUpdates: true,
+		IPAddress:          "203.0.113.1",
+		Certificate:        []byte("-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----\n"),
+	}
+	if err := client.SetWebhook(ctx, "https://example.com/webhook", opts); err != nil {
+		t.Fatalf("SetWebhook failed: %v", err)
+	}
+	if url := server.WebhookURL(); url != "https://example.com/webhook" {
+		t.Fatalf("unexpected webhook URL %q", url)
+	}
+	if token := server.SecretToken(); token != opts.SecretToken {
+		t.Fatalf("unexpected secret token %q", token)
+	}
+	for name, want := range map[string]string{
+		"allowed_updates":      `["message","my_chat_member"]`,
+		"max_connections":      "10",
+		"drop_pending_updates": "true",
+		"ip_address":           "203.0.113.1",
+	} {
+		if got := server.WebhookParam(name); got != want {
+			t.Fatalf("expected %s %q, got %q", name, want, got)
+		}
+	}
+	if cert := server.WebhookCertificate(); string(cert) != string(opts.Certificate) {
+		t.Fatalf("unexpected certificate %q", cert)
+	}
+
+	if err := client.DeleteWebhook(ctx); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if url := server.WebhookURL(); url != "" {
+		t.Fatalf("expected webhook to be deleted, got %q", url)
+	}
+}
+
+func TestGetWebhookInfo(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	opts := telegram.WebhookOptions{AllowedUpdates: []string{"message"}, MaxConnections: 5}
+	if err := client.SetWebhook(ctx, "https://example.com/webhook", opts); err != nil {
+		t.Fatalf("SetWebhook failed: %v", err)
+	}
+	server.Inject(telegram.Update{Message: &telegram.Message{Text: "/help"}})
+	errorAt := time.Unix(1700000000, 0)
+	server.WebhookError("Connection refused", errorAt)
+
+	info, err := client.GetWebhookInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetWebhookInfo failed: %v", err)
+	}
+	if info.URL != "https://example.com/webhook" || info.MaxConnections != 5 ||
+		len(info.AllowedUpdates) != 1 || info.AllowedUpdates[0] != "message" {
+		t.Fatalf("unexpected webhook info %+v", info)
+	}
+	if info.PendingUpdateCount != 1 {
+		t.Fatalf("expected 1 pending update, got %d", info.PendingUpdateCount)
+	}
+	if info.LastErrorMessage != "Connection refused" || info.LastErrorDate != errorAt.Unix() {
+		t.Fatalf("unexpected last error %q at %d", info.LastErrorMessage, info.LastErrorDate)
+	}
+}
+
+func TestWrongToken(t *testing.T) {
+	server := telegramtest.NewServer(testToken)
+	defer server.Close()
+
+	client := telegram.NewClientWithOptions("wrong", telegram.Options{BaseURL: server.URL})
+	if _, err := client.GetUpdates(context.Background(), 0, 0); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
+
+func TestSecretToken(t *testing.T) {
+	token, err := telegram.GenerateSecretToken()
+	if err != nil {
+		t.Fatalf("GenerateSecretToken failed: %v", err)
+	}
+	if err := telegram.ValidateSecretToken(token); err != nil {
+		t.Fatalf("generated token is invalid: %v", err)
+	}
+	if err := telegram.ValidateSecretToken("bad token!"); err == nil {
+		t.Fatal("expected token with spaces to be invalid")
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	if telegram.CheckSecretToken(r, token) {
+		t.Fatal("expected request without header to be rejected")
+	}
+	r.Header.Set(telegram.SecretTokenHeader, token+"x")
+	if telegram.CheckSecretToken(r, token) {
+		t.Fatal("expected request with wrong token to be rejected")
+	}
+	r.Header.Set(telegram.SecretTokenHeader, token)
+	if !telegram.CheckSecretToken(r, token) {
+		t.Fatal("expected request with token to be accepted")
+	}
+}
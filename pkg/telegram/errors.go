@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIError represents unsuccessful Bot API response
+type APIError struct {
+	Method      string
+	ErrorCode   int
+	Description string
+	Parameters  *ResponseParameters
+}
+
+// Error implements error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d in %s: %s", e.ErrorCode, e.Method, e.Description)
+}
+
+// RetryAfter returns delay requested by Telegram, zero if not set
+func (e *APIError) RetryAfter() time.Duration {
+	if e.Parameters == nil {
+		return 0
+	}
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+// Retryable reports whether request may succeed if repeated: flood control
+// (429) and server-side errors (5xx). Other errors, like 400 "chat not found"
+// or 403 "bot was blocked by the user", are permanent.
+func (e *APIError) Retryable() bool {
+	return e.ErrorCode == http.StatusTooManyRequests || e.ErrorCode >= http.StatusInternalServerError
+}
+
+// MigrateToChatID returns supergroup chat ID if request failed because group
+// was migrated, zero otherwise
+func MigrateToChatID(err error) int64 {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Parameters != nil {
+		return apiErr.Parameters.MigrateToChatID
+	}
+	return 0
+}
+
+// ChatUnavailable reports whether messages can't be delivered to chat
+// anymore: bot was blocked or kicked (403), chat doesn't exist or was
+// migrated to supergroup (400)
+func (e *APIError) ChatUnavailable() bool {
+	switch e.ErrorCode {
+	case http.StatusForbidden:
+		return true
+	case http.StatusBadRequest:
+		if e.Parameters != nil && e.Parameters.MigrateToChatID != 0 {
+			return true
+		}
+		description := strings.ToLower(e.Description)
+		return strings.Contains(description, "chat not found") ||
+			strings.Contains(description, "upgraded to a supergroup")
+	}
+	return false
+}
+
+// IsChatUnavailable reports whether err means that messages can't be
+// delivered to chat anymore
+func IsChatUnavailable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ChatUnavailable()
+}
+
+// IsRetryable reports whether failed request may succeed if repeated.
+// Network and decoding errors are considered transient, context
+// cancellation is not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return true
+}
+
+// decodeResponse decodes Bot API response envelope into result. Unsuccessful
+// responses are returned as *APIError.
+func decodeResponse(method string, resp *http.Response, result interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var envelope APIResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			// Not a Bot API response, e.g. error page of proxy
+			return &APIError{
+				Method:      method,
+				ErrorCode:   resp.StatusCode,
+				Description: http.StatusText(resp.StatusCode),
+			}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !envelope.OK {
+		code := envelope.ErrorCode
+		if code == 0 {
+			code = resp.StatusCode
+		}
+		return &APIError{
+			Method:      method,
+			ErrorCode:   code,
+			Description: envelope.Description,
+			Parameters:  envelope.Parameters,
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package telegram
+
+import "testing"
+
+func TestMigrationsBounded(t *testing.T) {
+	client := NewClientWithOptions("123:test", Options{})
+
+	for i := int64(1); i <= maxMigrations+10; i++ {
+		client.migrate(-i, -1000-i)
+	}
+	// Repeated migration doesn't take another slot
+	client.migrate(-(maxMigrations + 10), -1)
+
+	if n := len(client.migrations); n != maxMigrations {
+		t.Fatalf("expected %d remembered migrations, got %d", maxMigrations, n)
+	}
+	if chatID := client.migratedChatID(-1); chatID != -1 {
+		t.Fatalf("expected oldest migration to be forgotten, got chat %d", chatID)
+	}
+	if chatID := client.migratedChatID(-11); chatID != -1011 {
+		t.Fatalf("expected migration of chat -11 to be kept, got chat %d", chatID)
+	}
+	if chatID := client.migratedChatID(-(maxMigrations + 10)); chatID != -1 {
+		t.Fatalf("expected latest migration target, got chat %d", chatID)
+	}
+}
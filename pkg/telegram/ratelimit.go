@@ -0,0 +1,387 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimitQueueFull is returned when too many messages wait for rate limiter
+var ErrRateLimitQueueFull = errors.New("rate limiter queue is full")
+
+// Rate is number of requests allowed per period
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+// unlimited reports whether rate doesn't limit anything
+func (r Rate) unlimited() bool {
+	return r.Count <= 0 || r.Per <= 0
+}
+
+// RateLimits configures outbound message rate limits. Zero Rate means unlimited.
+type RateLimits struct {
+	Global    Rate // all chats together
+	PerChat   Rate // single chat
+	PerGroup  Rate // single group chat (negative chat ID), applied on top of PerChat
+	QueueSize int  // maximum number of waiting messages, 1000 by default
+}
+
+// DefaultRateLimits returns limits recommended by Telegram: 30 messages per
+// second overall, 1 per second in single chat and 20 per minute in groups
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		Global:    Rate{Count: 30, Per: time.Second},
+		PerChat:   Rate{Count: 1, Per: time.Second},
+		PerGroup:  Rate{Count: 20, Per: time.Minute},
+		QueueSize: 1000,
+	}
+}
+
+// RateLimiterStats shows how much outbound messages are throttled
+type RateLimiterStats struct {
+	QueueDepth int           // messages waiting now
+	Chats      int           // chats with waiting messages
+	Sent       uint64        // messages let through
+	Throttled  uint64        // messages that had to wait
+	Rejected   uint64        // messages rejected because queue was full
+	TotalWait  time.Duration // time spent waiting by all throttled messages
+	MaxWait    time.Duration // longest wait of single message
+}
+
+// bucket is token bucket; nil bucket is unlimited
+type bucket struct {
+	tokens   float64
+	capacity float64
+	interval time.Duration // time to refill one token
+	last     time.Time
+}
+
+// newBucket creates full bucket for rate, nil if rate is unlimited
+func newBucket(rate Rate, now time.Time) *bucket {
+	if rate.unlimited() {
+		return nil
+	}
+	return &bucket{
+		tokens:   float64(rate.Count),
+		capacity: float64(rate.Count),
+		interval: rate.Per / time.Duration(rate.Count),
+		last:     now,
+	}
+}
+
+// refill adds tokens accumulated since last refill
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(b.interval)
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+	}
+}
+
+// readyAt returns when bucket will have token
+func (b *bucket) readyAt(now time.Time) time.Time {
+	if b == nil {
+		return now
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		return now
+	}
+	return now.Add(time.Duration((1 - b.tokens) * float64(b.interval)))
+}
+
+// take consumes token, bucket must be ready
+func (b *bucket) take() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+// full reports whether bucket is refilled completely
+func (b *bucket) full(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.refill(now)
+	return b.tokens >= b.capacity
+}
+
+// waiter is message waiting for its turn
+type waiter struct {
+	ready    chan struct{}
+	enqueued time.Time
+}
+
+// chatQueue holds chat's buckets and waiting messages
+type chatQueue struct {
+	buckets []*bucket
+	waiters []*waiter
+}
+
+// readyAt returns when all chat's buckets will have token
+func (cq *chatQueue) readyAt(now time.Time) time.Time {
+	at := now
+	for _, b := range cq.buckets {
+		if t := b.readyAt(now); t.After(at) {
+			at = t
+		}
+	}
+	return at
+}
+
+// rateLimiter limits outbound messages globally and per chat. Waiting
+// messages are let through round-robin over chats, so single busy chat
+// doesn't delay others.
+type rateLimiter struct {
+	limits  RateLimits
+	mu      sync.Mutex
+	global  *bucket
+	chats   map[int64]*chatQueue
+	ring    []int64 // chats with waiting messages in round-robin order
+	queued  int
+	sweepAt int // number of chats to clean up idle ones at
+	running bool
+	wake    chan struct{}
+	stats   RateLimiterStats
+}
+
+// chatSweepThreshold is minimal number of tracked chats to look for idle ones
+const chatSweepThreshold = 1024
+
+// newRateLimiter creates rate limiter
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	if limits.QueueSize <= 0 {
+		limits.QueueSize = DefaultRateLimits().QueueSize
+	}
+	return &rateLimiter{
+		limits:  limits,
+		global:  newBucket(limits.Global, time.Now()),
+		chats:   make(map[int64]*chatQueue),
+		sweepAt: chatSweepThreshold,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// wait blocks until message to chat may be sent
+func (rl *rateLimiter) wait(ctx context.Context, chatID int64) error {
+	rl.mu.Lock()
+	now := time.Now()
+	cq := rl.chat(chatID, now)
+
+	// Nobody is waiting, so message may go right away if tokens are available
+	if rl.queued == 0 && !rl.global.readyAt(now).After(now) && !cq.readyAt(now).After(now) {
+		rl.global.take()
+		for _, b := range cq.buckets {
+			b.take()
+		}
+		rl.stats.Sent++
+		rl.mu.Unlock()
+		return nil
+	}
+
+	if rl.queued >= rl.limits.QueueSize {
+		rl.stats.Rejected++
+		rl.mu.Unlock()
+		return ErrRateLimitQueueFull
+	}
+
+	w := &waiter{ready: make(chan struct{}), enqueued: now}
+	cq.waiters = append(cq.waiters, w)
+	if len(cq.waiters) == 1 {
+		rl.ring = append(rl.ring, chatID)
+	}
+	rl.queued++
+	rl.stats.Throttled++
+
+	if rl.running {
+		rl.notify()
+	} else {
+		rl.running = true
+		go rl.run()
+	}
+	rl.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for i, queued := range cq.waiters {
+		if queued == w {
+			cq.waiters = append(cq.waiters[:i], cq.waiters[i+1:]...)
+			rl.queued--
+			if len(cq.waiters) == 0 {
+				rl.removeFromRing(chatID)
+			}
+			return ctx.Err()
+		}
+	}
+
+	// Turn came while context was being cancelled
+	return nil
+}
+
+// statistics returns current statistics
+func (rl *rateLimiter) statistics() RateLimiterStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	stats := rl.stats
+	stats.QueueDepth = rl.queued
+	stats.Chats = len(rl.ring)
+	return stats
+}
+
+// run lets waiting messages through until queue is empty
+func (rl *rateLimiter) run() {
+	for {
+		rl.mu.Lock()
+		now := time.Now()
+		next, idle := rl.dispatch(now)
+		if idle {
+			rl.running = false
+			rl.mu.Unlock()
+			return
+		}
+		rl.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+		case <-rl.wake:
+		}
+		timer.Stop()
+	}
+}
+
+// dispatch lets through all messages that may be sent at now and returns
+// when next one may be sent; idle is true if nobody waits
+func (rl *rateLimiter) dispatch(now time.Time) (next time.Time, idle bool) {
+	for len(rl.ring) > 0 {
+		if at := rl.global.readyAt(now); at.After(now) {
+			return at, false
+		}
+
+		granted := false
+		next = time.Time{}
+		for i := 0; i < len(rl.ring); i++ {
+			chatID := rl.ring[0]
+			rl.ring = append(rl.ring[1:], chatID)
+
+			cq := rl.chats[chatID]
+			if at := cq.readyAt(now); at.After(now) {
+				if next.IsZero() || at.Before(next) {
+					next = at
+				}
+				continue
+			}
+
+			rl.grant(cq, now)
+			if len(cq.waiters) == 0 {
+				rl.ring = rl.ring[:len(rl.ring)-1]
+			}
+			granted = true
+			break
+		}
+
+		if !granted {
+			return next, false
+		}
+	}
+	return time.Time{}, true
+}
+
+// grant lets first waiting message of chat through
+func (rl *rateLimiter) grant(cq *chatQueue, now time.Time) {
+	rl.global.take()
+	for _, b := range cq.buckets {
+		b.take()
+	}
+
+	w := cq.waiters[0]
+	cq.waiters[0] = nil
+	cq.waiters = cq.waiters[1:]
+	rl.queued--
+
+	wait := now.Sub(w.enqueued)
+	rl.stats.Sent++
+	rl.stats.TotalWait += wait
+	if wait > rl.stats.MaxWait {
+		rl.stats.MaxWait = wait
+	}
+	close(w.ready)
+}
+
+// chat returns chat's queue, creating it if needed
+func (rl *rateLimiter) chat(chatID int64, now time.Time) *chatQueue {
+	if cq, ok := rl.chats[chatID]; ok {
+		return cq
+	}
+
+	if len(rl.chats) >= rl.sweepAt {
+		rl.sweep(now)
+	}
+
+	cq := &chatQueue{}
+	if b := newBucket(rl.limits.PerChat, now); b != nil {
+		cq.buckets = append(cq.buckets, b)
+	}
+	if chatID < 0 {
+		if b := newBucket(rl.limits.PerGroup, now); b != nil {
+			cq.buckets = append(cq.buckets, b)
+		}
+	}
+	rl.chats[chatID] = cq
+	return cq
+}
+
+// sweep forgets idle chats with fully refilled buckets
+func (rl *rateLimiter) sweep(now time.Time) {
+	for chatID, cq := range rl.chats {
+		if len(cq.waiters) > 0 {
+			continue
+		}
+		idle := true
+		for _, b := range cq.buckets {
+			if !b.full(now) {
+				idle = false
+				break
+			}
+		}
+		if idle {
+			delete(rl.chats, chatID)
+		}
+	}
+
+	rl.sweepAt = 2 * len(rl.chats)
+	if rl.sweepAt < chatSweepThreshold {
+		rl.sweepAt = chatSweepThreshold
+	}
+}
+
+// removeFromRing removes chat from round-robin order
+func (rl *rateLimiter) removeFromRing(chatID int64) {
+	for i, id := range rl.ring {
+		if id == chatID {
+			rl.ring = append(rl.ring[:i], rl.ring[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify wakes run loop
+func (rl *rateLimiter) notify() {
+	select {
+	case rl.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,139 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"tg-timer/pkg/telegram"
+	"tg-timer/pkg/telegram/telegramtest"
+)
+
+func newLimitedClient(t *testing.T, limits telegram.RateLimits) (*telegram.HTTPClient, *telegramtest.Server) {
+	t.Helper()
+
+	server := telegramtest.NewServer(testToken)
+	t.Cleanup(server.Close)
+
+	client := telegram.NewClientWithOptions(testToken, telegram.Options{
+		BaseURL:    server.URL,
+		RateLimits: &limits,
+	})
+	return client, server
+}
+
+// waitQueueDepth waits until depth messages are queued in rate limiter
+func waitQueueDepth(t *testing.T, client *telegram.HTTPClient, depth int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for client.Stats().QueueDepth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected queue depth %d, got %d", depth, client.Stats().QueueDepth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimitPerChat(t *testing.T) {
+	client, _ := newLimitedClient(t, telegram.RateLimits{
+		PerChat: telegram.Rate{Count: 1, Per: 100 * time.Millisecond},
+	})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.SendMessage(context.Background(), 1, "hello"); err != nil {
+				t.Errorf("SendMessage failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("expected 3 messages to take at least 200ms, took %s", elapsed)
+	}
+
+	stats := client.Stats()
+	if stats.Sent != 3 || stats.Throttled != 2 || stats.QueueDepth != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats.MaxWait < 100*time.Millisecond || stats.TotalWait < stats.MaxWait {
+		t.Fatalf("unexpected wait times %+v", stats)
+	}
+}
+
+func TestRateLimitFairness(t *testing.T) {
+	client, server := newLimitedClient(t, telegram.RateLimits{
+		Global: telegram.Rate{Count: 1, Per: 20 * time.Millisecond},
+	})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	send := func(chatID int64) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.SendMessage(ctx, chatID, "hello"); err != nil {
+				t.Errorf("SendMessage failed: %v", err)
+			}
+		}()
+	}
+
+	// First message passes immediately, 5 more wait
+	for i := 0; i < 6; i++ {
+		send(1)
+	}
+	waitQueueDepth(t, client, 5)
+
+	send(2)
+	wg.Wait()
+
+	for i, msg := range server.SentMessages() {
+		if msg.ChatID == 2 {
+			if i > 2 {
+				t.Fatalf("expected chat 2 to be served before busy chat, sent at position %d", i)
+			}
+			return
+		}
+	}
+	t.Fatal("message to chat 2 was not sent")
+}
+
+func TestRateLimitQueueFull(t *testing.T) {
+	client, server := newLimitedClient(t, telegram.RateLimits{
+		PerChat:   telegram.Rate{Count: 1, Per: time.Hour},
+		QueueSize: 1,
+	})
+
+	if err := client.SendMessage(context.Background(), 1, "first"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waited := make(chan error, 1)
+	go func() { waited <- client.SendMessage(ctx, 1, "second") }()
+	waitQueueDepth(t, client, 1)
+
+	if err := client.SendMessage(context.Background(), 2, "third"); !errors.Is(err, telegram.ErrRateLimitQueueFull) {
+		t.Fatalf("expected ErrRateLimitQueueFull, got %v", err)
+	}
+
+	cancel()
+	if err := <-waited; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled wait, got %v", err)
+	}
+
+	stats := client.Stats()
+	if stats.QueueDepth != 0 || stats.Rejected != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if sent := server.SentMessages(); len(sent) != 1 {
+		t.Fatalf("expected 1 message sent, got %d", len(sent))
+	}
+}
@@ -0,0 +1,228 @@
+// Package telegramtest provides in-memory Telegram client and helpers for tests
+package telegramtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"tg-timer/pkg/telegram"
+)
+
+// SentMessage represents message sent through fake client
+type SentMessage struct {
+	ChatID int64
+	Text   string
+}
+
+// Client is in-memory telegram.Client that records sent messages and
+// returns injected updates from GetUpdates
+type Client struct {
+	mu           sync.Mutex
+	pending      []telegram.Update
+	nextUpdateID int
+	sent         []SentMessage
+	cursors      map[int64]int   // chatID -> index of next unread message in sent
+	failures     map[int64]error // chatID -> error returned by SendMessage
+	changed      chan struct{}   // closed and replaced on every change
+	webhookURL   string
+	webhookOpts  telegram.WebhookOptions
+	webhookError string
+	webhookErrAt int64
+}
+
+var _ telegram.Client = (*Client)(nil)
+
+// NewClient creates new fake client
+func NewClient() *Client {
+	return &Client{
+		nextUpdateID: 1,
+		cursors:      make(map[int64]int),
+		failures:     make(map[int64]error),
+		changed:      make(chan struct{}),
+	}
+}
+
+// GetUpdates returns injected updates with ID >= offset, waiting up to
+// timeout seconds for new ones
+func (c *Client) GetUpdates(ctx context.Context, offset int, timeout int) ([]telegram.Update, error) {
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		c.mu.Lock()
+		var updates []telegram.Update
+		remaining := c.pending[:0]
+		for _, update := range c.pending {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+				remaining = append(remaining, update)
+			}
+		}
+		c.pending = remaining
+		changed := c.changed
+		c.mu.Unlock()
+
+		if len(updates) > 0 {
+			return updates, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, nil
+		case <-changed:
+		}
+	}
+}
+
+// SendMessage records message or returns error set by FailChat
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.failures[chatID]; err != nil {
+		return err
+	}
+
+	c.sent = append(c.sent, SentMessage{ChatID: chatID, Text: text})
+	c.notifyLocked()
+	return nil
+}
+
+// SetWebhook records webhook URL
+func (c *Client) SetWebhook(ctx context.Context, webhookURL string, opts telegram.WebhookOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.webhookURL = webhookURL
+	c.webhookOpts = opts
+	return nil
+}
+
+// DeleteWebhook clears webhook URL
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.webhookURL = ""
+	c.webhookOpts = telegram.WebhookOptions{}
+	return nil
+}
+
+// GetWebhookInfo reports webhook set by SetWebhook and error set by
+// WebhookError; pending updates are injected but not yet received ones
+func (c *Client) GetWebhookInfo(ctx context.Context) (*telegram.WebhookInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info := &telegram.WebhookInfo{
+		URL:                  c.webhookURL,
+		HasCustomCertificate: len(c.webhookOpts.Certificate) > 0,
+		PendingUpdateCount:   len(c.pending),
+		IPAddress:            c.webhookOpts.IPAddress,
+		MaxConnections:       c.webhookOpts.MaxConnections,
+		AllowedUpdates:       c.webhookOpts.AllowedUpdates,
+	}
+	if c.webhookError != "" {
+		info.LastErrorMessage = c.webhookError
+		info.LastErrorDate = c.webhookErrAt
+	}
+	return info, nil
+}
+
+// WebhookError makes GetWebhookInfo report delivery error, empty message
+// clears it
+func (c *Client) WebhookError(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.webhookError = message
+	c.webhookErrAt = time.Now().Unix()
+}
+
+// WebhookURL returns currently set webhook URL
+func (c *Client) WebhookURL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.webhookURL
+}
+
+// WebhookOptions returns options of currently set webhook
+func (c *Client) WebhookOptions() telegram.WebhookOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.webhookOpts
+}
+
+// Inject queues update for GetUpdates, assigning UpdateID if it is zero
+func (c *Client) Inject(update telegram.Update) telegram.Update {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if update.UpdateID == 0 {
+		update.UpdateID = c.nextUpdateID
+	}
+	if update.UpdateID >= c.nextUpdateID {
+		c.nextUpdateID = update.UpdateID + 1
+	}
+
+	c.pending = append(c.pending, update)
+	c.notifyLocked()
+	return update
+}
+
+// FailChat makes SendMessage to chat return err, nil err restores delivery
+func (c *Client) FailChat(chatID int64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.failures, chatID)
+	} else {
+		c.failures[chatID] = err
+	}
+}
+
+// Messages returns all sent messages
+func (c *Client) Messages() []SentMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]SentMessage(nil), c.sent...)
+}
+
+// NextMessage waits up to timeout for next unread message sent to chat
+func (c *Client) NextMessage(chatID int64, timeout time.Duration) (SentMessage, bool) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		c.mu.Lock()
+		for i := c.cursors[chatID]; i < len(c.sent); i++ {
+			if c.sent[i].ChatID == chatID {
+				c.cursors[chatID] = i + 1
+				c.mu.Unlock()
+				return c.sent[i], true
+			}
+		}
+		c.cursors[chatID] = len(c.sent)
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-deadline.C:
+			return SentMessage{}, false
+		case <-changed:
+		}
+	}
+}
+
+// notifyLocked wakes goroutines waiting for updates or messages
+func (c *Client) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
@@ -0,0 +1,119 @@
+package telegramtest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tg-timer/pkg/telegram"
+)
+
+// DefaultTimeout is how long Conversation waits for expected message
+const DefaultTimeout = time.Second
+
+// QuietPeriod is how long Conversation waits to make sure nothing is sent
+const QuietPeriod = 50 * time.Millisecond
+
+// Conversation scripts dialog between user and bot in single chat, e.g.
+//
+//	conv.Send("/timer 5s").
+//		Expect("Таймер на 5 секунд установлен.").
+//		Advance(5 * time.Second).
+//		Expect("Время вышло!")
+type Conversation struct {
+	t       testing.TB
+	client  *Client
+	chat    telegram.Chat
+	from    telegram.User
+	advance func(time.Duration)
+	timeout time.Duration
+}
+
+// NewConversation starts conversation in private chat. Advance is called to
+// move bot's clock forward, it may be nil if conversation doesn't use Advance.
+func NewConversation(t testing.TB, client *Client, chatID int64, advance func(time.Duration)) *Conversation {
+	return &Conversation{
+		t:       t,
+		client:  client,
+		chat:    telegram.Chat{ID: chatID, Type: "private"},
+		from:    telegram.User{ID: chatID, FirstName: "Test"},
+		advance: advance,
+		timeout: DefaultTimeout,
+	}
+}
+
+// InGroup makes following messages come from group chat
+func (c *Conversation) InGroup() *Conversation {
+	c.chat.Type = "group"
+	return c
+}
+
+// WithLanguage sets sender's language code
+func (c *Conversation) WithLanguage(code string) *Conversation {
+	c.from.LanguageCode = code
+	return c
+}
+
+// Send injects text message from user
+func (c *Conversation) Send(text string) *Conversation {
+	c.t.Helper()
+
+	from := c.from
+	c.client.Inject(telegram.Update{
+		Message: &telegram.Message{
+			From: &from,
+			Text: text,
+			Chat: c.chat,
+		},
+	})
+	return c
+}
+
+// Advance moves bot's clock forward
+func (c *Conversation) Advance(d time.Duration) *Conversation {
+	c.t.Helper()
+
+	if c.advance == nil {
+		c.t.Fatal("conversation has no clock to advance")
+	}
+	c.advance(d)
+	return c
+}
+
+// Expect waits for next message to chat and checks its text
+func (c *Conversation) Expect(text string) *Conversation {
+	c.t.Helper()
+
+	msg, ok := c.client.NextMessage(c.chat.ID, c.timeout)
+	if !ok {
+		c.t.Fatalf("chat %d: expected %q, got nothing", c.chat.ID, text)
+	}
+	if msg.Text != text {
+		c.t.Fatalf("chat %d: expected %q, got %q", c.chat.ID, text, msg.Text)
+	}
+	return c
+}
+
+// ExpectContains waits for next message to chat and checks it contains substr
+func (c *Conversation) ExpectContains(substr string) *Conversation {
+	c.t.Helper()
+
+	msg, ok := c.client.NextMessage(c.chat.ID, c.timeout)
+	if !ok {
+		c.t.Fatalf("chat %d: expected message containing %q, got nothing", c.chat.ID, substr)
+	}
+	if !strings.Contains(msg.Text, substr) {
+		c.t.Fatalf("chat %d: expected message containing %q, got %q", c.chat.ID, substr, msg.Text)
+	}
+	return c
+}
+
+// ExpectNothing checks that no message is sent to chat during QuietPeriod
+func (c *Conversation) ExpectNothing() *Conversation {
+	c.t.Helper()
+
+	if msg, ok := c.client.NextMessage(c.chat.ID, QuietPeriod); ok {
+		c.t.Fatalf("chat %d: expected no message, got %q", c.chat.ID, msg.Text)
+	}
+	return c
+}
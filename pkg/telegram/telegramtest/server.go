@@ -0,0 +1,437 @@
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-timer/pkg/telegram"
+)
+
+// Fault describes injected failure of single Bot API request
+type Fault struct {
+	Status      int           // HTTP status code, 200 by default
+	ErrorCode   int           // error_code in response, Status by default
+	Description string        // description in response
+	RetryAfter  int           // parameters.retry_after in response, if set
+	MigrateTo   int64         // parameters.migrate_to_chat_id in response, if set
+	Body        string        // raw response body (e.g., malformed JSON), overrides above
+	Delay       time.Duration // delay before response
+}
+
+// Server is fake Telegram Bot API server for tests. It implements getUpdates,
+// sendMessage, setWebhook and deleteWebhook and supports fault injection.
+type Server struct {
+	*httptest.Server
+
+	token        string
+	mu           sync.Mutex
+	updates      []telegram.Update
+	nextUpdateID int
+	sent         []telegram.SendMessageRequest
+	webhook      map[string]string  // parameters of last setWebhook
+	certificate  []byte             // certificate uploaded by last setWebhook
+	webhookError string             // last_error_message reported by getWebhookInfo
+	webhookErrAt int64              // last_error_date reported by getWebhookInfo
+	faults       map[string][]Fault // method -> faults for next requests
+	requests     map[string]int     // method -> number of requests
+	changed      chan struct{}      // closed and replaced when updates change
+}
+
+// NewServer starts fake Bot API server accepting token
+func NewServer(token string) *Server {
+	s := &Server{
+		token:        token,
+		nextUpdateID: 1,
+		faults:       make(map[string][]Fault),
+		requests:     make(map[string]int),
+		changed:      make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Inject queues update for getUpdates, assigning UpdateID if it is zero
+func (s *Server) Inject(update telegram.Update) telegram.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.UpdateID == 0 {
+		update.UpdateID = s.nextUpdateID
+	}
+	if update.UpdateID >= s.nextUpdateID {
+		s.nextUpdateID = update.UpdateID + 1
+	}
+
+	s.updates = append(s.updates, update)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return update
+}
+
+// Fail makes next requests to method fail with faults, one fault per request
+func (s *Server) Fail(method string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[method] = append(s.faults[method], faults...)
+}
+
+// SentMessages returns messages received by sendMessage
+func (s *Server) SentMessages() []telegram.SendMessageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]telegram.SendMessageRequest(nil), s.sent...)
+}
+
+// WebhookURL returns URL set by setWebhook
+func (s *Server) WebhookURL() string {
+	return s.WebhookParam("url")
+}
+
+// SecretToken returns secret token set by setWebhook
+func (s *Server) SecretToken() string {
+	return s.WebhookParam("secret_token")
+}
+
+// WebhookParam returns parameter of last setWebhook, empty after deleteWebhook
+func (s *Server) WebhookParam(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.webhook[name]
+}
+
+// WebhookCertificate returns certificate uploaded by setWebhook
+func (s *Server) WebhookCertificate() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.certificate
+}
+
+// WebhookError makes getWebhookInfo report delivery error
+func (s *Server) WebhookError(message string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhookError = message
+	s.webhookErrAt = at.Unix()
+}
+
+// Requests returns number of requests to method, including failed ones
+func (s *Server) Requests(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[method]
+}
+
+// handle routes /bot<token>/<method> requests
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	prefix := "bot" + s.token + "/"
+	if !strings.HasPrefix(path, prefix) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	method := strings.TrimPrefix(path, prefix)
+
+	s.mu.Lock()
+	s.requests[method]++
+	var fault *Fault
+	if faults := s.faults[method]; len(faults) > 0 {
+		fault = &faults[0]
+		s.faults[method] = faults[1:]
+	}
+	s.mu.Unlock()
+
+	if fault != nil && s.applyFault(w, r, fault) {
+		return
+	}
+
+	params, err := requestParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	switch method {
+	case "getUpdates":
+		s.getUpdates(w, r, params)
+	case "sendMessage":
+		s.sendMessage(w, params)
+	case "setWebhook":
+		s.setWebhook(w, r, params)
+	case "deleteWebhook":
+		s.mu.Lock()
+		s.webhook = nil
+		s.certificate = nil
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "getWebhookInfo":
+		s.getWebhookInfo(w)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+// applyFault delays and writes faulty response, returns false if request
+// should be processed normally after delay
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request, fault *Fault) bool {
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if fault.Body != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusOrOK(fault.Status))
+		fmt.Fprint(w, fault.Body)
+		return true
+	}
+
+	if fault.Status == 0 || fault.Status == http.StatusOK {
+		// Only delay was requested
+		return false
+	}
+
+	code := fault.ErrorCode
+	if code == 0 {
+		code = fault.Status
+	}
+	description := fault.Description
+	if description == "" {
+		description = http.StatusText(fault.Status)
+	}
+
+	writeJSON(w, fault.Status, apiResponse{
+		OK:          false,
+		ErrorCode:   code,
+		Description: description,
+		Parameters:  responseParameters(fault),
+	})
+	return true
+}
+
+// getUpdates returns updates with ID >= offset, long polling up to timeout
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	offset, _ := strconv.Atoi(params["offset"])
+	timeout, _ := strconv.Atoi(params["timeout"])
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		var updates []telegram.Update
+		remaining := s.updates[:0]
+		for _, update := range s.updates {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+				remaining = append(remaining, update)
+			}
+		}
+		s.updates = remaining
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(updates) > 0 || timeout <= 0 {
+			if updates == nil {
+				updates = []telegram.Update{}
+			}
+			writeResult(w, updates)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			writeResult(w, []telegram.Update{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// setWebhook records webhook parameters and uploaded certificate
+func (s *Server) setWebhook(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if params["url"] == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: bad webhook: URL is empty")
+		return
+	}
+
+	var certificate []byte
+	if r.MultipartForm != nil && len(r.MultipartForm.File["certificate"]) > 0 {
+		file, err := r.MultipartForm.File["certificate"][0].Open()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Bad Request: can't read certificate")
+			return
+		}
+		defer file.Close()
+
+		if certificate, err = io.ReadAll(file); err != nil {
+			writeError(w, http.StatusBadRequest, "Bad Request: can't read certificate")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.webhook = params
+	s.certificate = certificate
+	s.mu.Unlock()
+
+	writeResult(w, true)
+}
+
+// getWebhookInfo reports webhook set by last setWebhook
+func (s *Server) getWebhookInfo(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := telegram.WebhookInfo{
+		URL:                  s.webhook["url"],
+		HasCustomCertificate: len(s.certificate) > 0,
+		IPAddress:            s.webhook["ip_address"],
+		LastErrorDate:        s.webhookErrAt,
+		LastErrorMessage:     s.webhookError,
+	}
+	if info.URL != "" {
+		info.PendingUpdateCount = len(s.updates)
+	}
+	if maxConnections, err := strconv.Atoi(s.webhook["max_connections"]); err == nil {
+		info.MaxConnections = maxConnections
+	}
+	if allowed := s.webhook["allowed_updates"]; allowed != "" {
+		json.Unmarshal([]byte(allowed), &info.AllowedUpdates)
+	}
+	writeResult(w, info)
+}
+
+// sendMessage records message
+func (s *Server) sendMessage(w http.ResponseWriter, params map[string]string) {
+	chatID, err := strconv.ParseInt(params["chat_id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat_id is empty")
+		return
+	}
+	if params["text"] == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: message text is empty")
+		return
+	}
+
+	req := telegram.SendMessageRequest{
+		ChatID:    chatID,
+		Text:      params["text"],
+		ParseMode: params["parse_mode"],
+	}
+
+	s.mu.Lock()
+	s.sent = append(s.sent, req)
+	messageID := len(s.sent)
+	s.mu.Unlock()
+
+	writeResult(w, telegram.Message{
+		MessageID: messageID,
+		Text:      req.Text,
+		Chat:      telegram.Chat{ID: chatID},
+	})
+}
+
+// apiResponse represents Bot API response envelope
+type apiResponse struct {
+	OK          bool                   `json:"ok"`
+	Result      interface{}            `json:"result,omitempty"`
+	ErrorCode   int                    `json:"error_code,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// requestParams collects parameters from query string, form or JSON body
+func requestParams(r *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		params[key] = values[0]
+	}
+
+	if r.Method != http.MethodPost {
+		return params, nil
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("can't parse JSON: %w", err)
+		}
+		for key, value := range body {
+			switch v := value.(type) {
+			case string:
+				params[key] = v
+			case float64:
+				params[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				data, _ := json.Marshal(v)
+				params[key] = string(data)
+			}
+		}
+		return params, nil
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, fmt.Errorf("can't parse form: %w", err)
+	}
+	for key, values := range r.PostForm {
+		params[key] = values[0]
+	}
+	return params, nil
+}
+
+func responseParameters(fault *Fault) map[string]interface{} {
+	params := make(map[string]interface{})
+	if fault.RetryAfter != 0 {
+		params["retry_after"] = fault.RetryAfter
+	}
+	if fault.MigrateTo != 0 {
+		params["migrate_to_chat_id"] = fault.MigrateTo
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+func statusOrOK(status int) int {
+	if status == 0 {
+		return http.StatusOK
+	}
+	return status
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	writeJSON(w, http.StatusOK, apiResponse{OK: true, Result: result})
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	writeJSON(w, status, apiResponse{
+		OK:          false,
+		ErrorCode:   status,
+		Description: description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, response apiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
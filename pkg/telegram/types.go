@@ -1,21 +1,58 @@
 package telegram
 
+import "encoding/json"
+
 // Update represents a Telegram update structure
 type Update struct {
-	UpdateID int      `json:"update_id"`
-	Message  *Message `json:"message"`
+	UpdateID     int                `json:"update_id"`
+	Message      *Message           `json:"message"`
+	MyChatMember *ChatMemberUpdated `json:"my_chat_member,omitempty"`
 }
 
 // Message represents a Telegram message
 type Message struct {
-	MessageID int    `json:"message_id"`
-	Text      string `json:"text"`
-	Chat      Chat   `json:"chat"`
+	MessageID         int    `json:"message_id"`
+	From              *User  `json:"from,omitempty"`
+	Text              string `json:"text"`
+	Chat              Chat   `json:"chat"`
+	MigrateToChatID   int64  `json:"migrate_to_chat_id,omitempty"`   // service message: group migrated to supergroup
+	MigrateFromChatID int64  `json:"migrate_from_chat_id,omitempty"` // service message: supergroup migrated from group
+}
+
+// User represents a Telegram user or bot
+type User struct {
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
 }
 
 // Chat represents a Telegram chat
 type Chat struct {
-	ID int64 `json:"id"`
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // "private", "group", "supergroup" or "channel"
+}
+
+// ChatMemberUpdated represents change of chat member status, e.g. bot being
+// removed from group or blocked by user
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          User       `json:"from"`
+	Date          int64      `json:"date"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
+
+// ChatMember represents chat member status
+type ChatMember struct {
+	Status string `json:"status"` // "creator", "administrator", "member", "restricted", "left" or "kicked"
+	User   User   `json:"user"`
+}
+
+// Left reports whether member is no longer in chat
+func (m ChatMember) Left() bool {
+	return m.Status == "left" || m.Status == "kicked"
 }
 
 // SendMessageRequest represents request to send message
@@ -27,6 +64,15 @@ type SendMessageRequest struct {
 
 // APIResponse represents generic API response
 type APIResponse struct {
-	OK          bool   `json:"ok"`
-	Description string `json:"description,omitempty"`
+	OK          bool                `json:"ok"`
+	Result      json.RawMessage     `json:"result,omitempty"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
+}
+
+// ResponseParameters describes why request was unsuccessful
+type ResponseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"` // group was migrated to supergroup
+	RetryAfter      int   `json:"retry_after,omitempty"`        // seconds to wait before repeating request
 }
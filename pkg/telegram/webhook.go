@@ -1,45 +1,96 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"net/url"
+	"strconv"
 )
 
-// SetWebhook sets webhook for Telegram bot
-func (tc *HTTPClient) SetWebhook(ctx context.Context, webhookURL string) error {
-	params := url.Values{}
-	params.Add("url", webhookURL)
+// SecretTokenHeader is header with secret token in webhook requests
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookOptions represents optional setWebhook parameters
+type WebhookOptions struct {
+	SecretToken        string   // sent back in SecretTokenHeader of every webhook request
+	AllowedUpdates     []string // update types to receive, e.g. "message"; all except chat_member if empty
+	MaxConnections     int      // simultaneous HTTPS connections, 1-100, 40 if zero
+	DropPendingUpdates bool     // drop updates that arrived before webhook was set
+	IPAddress          string   // IP address to send requests to instead of resolving URL host
+	Certificate        []byte   // public key of self-signed certificate in PEM format
+}
 
-	url := tc.baseURL + "setWebhook?" + params.Encode()
+// WebhookInfo represents current webhook status
+type WebhookInfo struct {
+	URL                          string   `json:"url"`
+	HasCustomCertificate         bool     `json:"has_custom_certificate"`
+	PendingUpdateCount           int      `json:"pending_update_count"`
+	IPAddress                    string   `json:"ip_address,omitempty"`
+	LastErrorDate                int64    `json:"last_error_date,omitempty"`
+	LastErrorMessage             string   `json:"last_error_message,omitempty"`
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date,omitempty"`
+	MaxConnections               int      `json:"max_connections,omitempty"`
+	AllowedUpdates               []string `json:"allowed_updates,omitempty"`
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+// SetWebhook sets webhook for Telegram bot. Parameters are sent as multipart
+// form, so self-signed certificate can be uploaded.
+func (tc *HTTPClient) SetWebhook(ctx context.Context, webhookURL string, opts WebhookOptions) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
 
-	resp, err := tc.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+	fields := [][2]string{{"url", webhookURL}}
+	if opts.SecretToken != "" {
+		fields = append(fields, [2]string{"secret_token", opts.SecretToken})
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	if len(opts.AllowedUpdates) > 0 {
+		allowed, err := json.Marshal(opts.AllowedUpdates)
+		if err != nil {
+			return fmt.Errorf("failed to marshal allowed updates: %w", err)
+		}
+		fields = append(fields, [2]string{"allowed_updates", string(allowed)})
+	}
+	if opts.MaxConnections > 0 {
+		fields = append(fields, [2]string{"max_connections", strconv.Itoa(opts.MaxConnections)})
+	}
+	if opts.DropPendingUpdates {
+		fields = append(fields, [2]string{"drop_pending_updates", "true"})
+	}
+	if opts.IPAddress != "" {
+		fields = append(fields, [2]string{"ip_address", opts.IPAddress})
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", field[0], err)
+		}
 	}
 
-	var result APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	if len(opts.Certificate) > 0 {
+		part, err := form.CreateFormFile("certificate", "certificate.pem")
+		if err != nil {
+			return fmt.Errorf("failed to write certificate: %w", err)
+		}
+		if _, err := part.Write(opts.Certificate); err != nil {
+			return fmt.Errorf("failed to write certificate: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("failed to write form: %w", err)
 	}
 
-	if !result.OK {
-		return fmt.Errorf("API error: %s", result.Description)
+	req, err := http.NewRequestWithContext(ctx, "POST", tc.baseURL+"setWebhook", &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
 
-	return nil
+	return tc.do("setWebhook", req, nil)
 }
 
 // DeleteWebhook deletes webhook
@@ -51,24 +102,48 @@ func (tc *HTTPClient) DeleteWebhook(ctx context.Context) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := tc.client.Do(req)
+	return tc.do("deleteWebhook", req, nil)
+}
+
+// GetWebhookInfo returns current webhook status
+func (tc *HTTPClient) GetWebhookInfo(ctx context.Context) (*WebhookInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", tc.baseURL+"getWebhookInfo", nil)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	var info WebhookInfo
+	if err := tc.do("getWebhookInfo", req, &info); err != nil {
+		return nil, err
 	}
+	return &info, nil
+}
 
-	var result APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+// GenerateSecretToken returns random webhook secret token
+func GenerateSecretToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret token: %w", err)
 	}
+	return hex.EncodeToString(buf), nil
+}
 
-	if !result.OK {
-		return fmt.Errorf("API error: %s", result.Description)
+// ValidateSecretToken checks that token is accepted by Telegram: 1-256
+// characters A-Z, a-z, 0-9, _ and -
+func ValidateSecretToken(token string) error {
+	if len(token) == 0 || len(token) > 256 {
+		return fmt.Errorf("secret token must be 1-256 characters long, got %d", len(token))
+	}
+	for _, c := range token {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return fmt.Errorf("secret token contains invalid character %q", c)
+		}
 	}
-
 	return nil
 }
+
+// CheckSecretToken reports whether webhook request carries secret token,
+// comparing in constant time
+func CheckSecretToken(r *http.Request, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretTokenHeader)), []byte(token)) == 1
+}